@@ -19,11 +19,13 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"strings"
 
 	"github.com/linuxsuren/api-testing/pkg/testing"
 	"github.com/linuxsuren/atest-ext-store-orm/pkg"
 	"github.com/modelcontextprotocol/go-sdk/mcp"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 )
 
 func newMCPCommand() (c *cobra.Command) {
@@ -37,33 +39,68 @@ func newMCPCommand() (c *cobra.Command) {
 	flags := c.Flags()
 	flags.StringVarP(&opt.mode, "mode", "", "http", "Server mode, one of http/stdio/sse")
 	flags.IntVarP(&opt.port, "port", "", 7072, "Server port for http or sse mode")
-	flags.StringVarP(&opt.url, "url", "", "", "Database URL")
-	flags.StringVarP(&opt.username, "username", "", "", "Database username")
-	flags.StringVarP(&opt.password, "password", "", "", "Database password")
-	flags.StringVarP(&opt.database, "database", "", "", "Database name")
-	flags.StringVarP(&opt.driver, "driver", "", "mysql", "Database driver, one of mysql/postgres/sqlite")
+	flags.BoolVarP(&opt.readOnly, "readonly", "", false, "Reject the SQL statements which might write, e.g. INSERT, DELETE or DROP")
+	opt.addFlags(flags)
 	return
 }
 
 type mcpOption struct {
-	mode     string
-	port     int
-	url      string
-	username string
-	password string
-	database string
-	driver   string
+	dbOption
+	mode string
+	port int
 }
 
-func (o *mcpOption) preRunE(c *cobra.Command, args []string) (err error) {
-	if o.url = getValueOrEnv(o.url, "DB_URL"); o.url == "" {
+type dbOption struct {
+	url        string
+	username   string
+	password   string
+	database   string
+	driver     string
+	properties map[string]string
+	readOnly   bool
+}
+
+func (o *dbOption) addFlags(flags *pflag.FlagSet) {
+	flags.StringVarP(&o.url, "url", "", "", "Database URL")
+	flags.StringVarP(&o.username, "username", "", "", "Database username")
+	flags.StringVarP(&o.password, "password", "", "", "Database password")
+	flags.StringVarP(&o.database, "database", "", "", "Database name")
+	flags.StringVarP(&o.driver, "driver", "", "mysql", "Database driver, one of mysql/postgres/sqlite/sqlite-purego/sqlserver/clickhouse/duckdb")
+	flags.StringToStringVarP(&o.properties, "property", "", nil, "Store properties, e.g. --property sslMode=require --property params=timeout=5s")
+}
+
+func (o *dbOption) preRunE(c *cobra.Command, args []string) (err error) {
+	o.driver = getValueOrEnv(o.driver, "DB_DRIVER")
+	// the embedded databases use an in-memory or local database without the URL
+	embedded := strings.HasPrefix(o.driver, "sqlite") || o.driver == pkg.DialectorDuckDB
+	_, hasDSN := o.properties["dsn"]
+	if o.url = getValueOrEnv(o.url, "DB_URL"); o.url == "" && !embedded && !hasDSN {
 		err = fmt.Errorf("database url is required")
 		return
 	}
 	o.username = getValueOrEnv(o.username, "DB_USERNAME")
 	o.password = getValueOrEnv(o.password, "DB_PASSWORD")
 	o.database = getValueOrEnv(o.database, "DB_DATABASE")
-	o.driver = getValueOrEnv(o.driver, "DB_DRIVER")
+	return
+}
+
+func (o *dbOption) toStore() (store *testing.Store) {
+	store = &testing.Store{
+		URL:      o.url,
+		Username: o.username,
+		Password: o.password,
+		Properties: map[string]string{
+			"database": o.database,
+			"driver":   o.driver,
+		},
+	}
+	// the same properties as the store of the server, e.g. sslMode, params or dsn
+	for key, value := range o.properties {
+		store.Properties[key] = value
+	}
+	if o.readOnly {
+		store.Properties["readOnly"] = "true"
+	}
 	return
 }
 
@@ -86,17 +123,7 @@ func (o *mcpOption) runE(c *cobra.Command, args []string) (err error) {
 		Title: "ORM Database MCP Server",
 	}, opts)
 
-	store := &testing.Store{
-		URL:      o.url,
-		Username: o.username,
-		Password: o.password,
-		Properties: map[string]string{
-			"database": o.database,
-			"driver":   o.driver,
-		},
-	}
-
-	dbServer := pkg.NewMcpServer(store)
+	dbServer := pkg.NewMcpServer(o.toStore())
 	mcp.AddTool(server, &mcp.Tool{
 		Name:        "database-query",
 		Description: "Query the database by SQL",
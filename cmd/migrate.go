@@ -0,0 +1,153 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"github.com/linuxsuren/atest-ext-store-orm/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newMigrateCommand() (c *cobra.Command) {
+	opt := &migrateOption{}
+	c = &cobra.Command{
+		Use:   "migrate",
+		Short: "Manage the schema migrations of the store",
+	}
+	opt.addFlags(c.PersistentFlags())
+
+	c.AddCommand(&cobra.Command{
+		Use:     "status",
+		Short:   "Print the state of all the migrations",
+		PreRunE: opt.preRunE,
+		RunE:    opt.runStatus,
+	})
+
+	up := &cobra.Command{
+		Use:     "up",
+		Short:   "Apply the pending migrations",
+		PreRunE: opt.preRunE,
+		RunE:    opt.runUp,
+	}
+	up.Flags().IntVarP(&opt.target, "to", "", 0, "The target version, 0 means the latest one")
+	up.Flags().BoolVarP(&opt.dryRun, "dry-run", "", false, "Only print the migrations to be applied")
+	c.AddCommand(up)
+
+	down := &cobra.Command{
+		Use:     "down",
+		Short:   "Revert the applied migrations",
+		PreRunE: opt.preRunE,
+		RunE:    opt.runDown,
+	}
+	down.Flags().IntVarP(&opt.target, "to", "", -1, "The target version, -1 means reverting the last one")
+	down.Flags().BoolVarP(&opt.dryRun, "dry-run", "", false, "Only print the migrations to be reverted")
+	c.AddCommand(down)
+
+	c.AddCommand(&cobra.Command{
+		Use:     "dry-run",
+		Short:   "Print the pending migrations without applying them",
+		PreRunE: opt.preRunE,
+		RunE: func(c *cobra.Command, args []string) error {
+			opt.dryRun = true
+			return opt.runUp(c, args)
+		},
+	})
+	return
+}
+
+type migrateOption struct {
+	dbOption
+	target int
+	dryRun bool
+}
+
+func (o *migrateOption) runStatus(c *cobra.Command, args []string) (err error) {
+	var migrator *pkg.SchemaMigrator
+	if migrator, err = pkg.OpenSchemaMigrator(o.toStore()); err != nil {
+		return
+	}
+	defer migrator.Close()
+
+	var states []pkg.MigrationState
+	if states, err = migrator.Status(); err != nil {
+		return
+	}
+
+	var current int
+	if current, err = migrator.Current(); err != nil {
+		return
+	}
+	c.Printf("current version: %d, latest version: %d\n", current, migrator.Latest())
+	for _, state := range states {
+		status := "pending"
+		if state.Applied {
+			status = "applied"
+		}
+		c.Printf("%d\t%s\t%s\n", state.Version, status, state.Name)
+	}
+	return
+}
+
+func (o *migrateOption) runUp(c *cobra.Command, args []string) (err error) {
+	var migrator *pkg.SchemaMigrator
+	if migrator, err = pkg.OpenSchemaMigrator(o.toStore()); err != nil {
+		return
+	}
+	defer migrator.Close()
+
+	var migrations []pkg.Migration
+	if migrations, err = migrator.Up(o.target, o.dryRun); err == nil {
+		o.printMigrations(c, "apply", migrations)
+	}
+	return
+}
+
+func (o *migrateOption) runDown(c *cobra.Command, args []string) (err error) {
+	var migrator *pkg.SchemaMigrator
+	if migrator, err = pkg.OpenSchemaMigrator(o.toStore()); err != nil {
+		return
+	}
+	defer migrator.Close()
+
+	target := o.target
+	if target < 0 {
+		var current int
+		if current, err = migrator.Current(); err != nil {
+			return
+		}
+		target = current - 1
+	}
+
+	var migrations []pkg.Migration
+	if migrations, err = migrator.Down(target, o.dryRun); err == nil {
+		o.printMigrations(c, "revert", migrations)
+	}
+	return
+}
+
+func (o *migrateOption) printMigrations(c *cobra.Command, action string, migrations []pkg.Migration) {
+	if len(migrations) == 0 {
+		c.Println("nothing to", action)
+		return
+	}
+
+	prefix := ""
+	if o.dryRun {
+		prefix = "[dry-run] "
+	}
+	for _, migration := range migrations {
+		c.Printf("%s%s %d: %s\n", prefix, action, migration.Version, migration.Name)
+	}
+}
@@ -0,0 +1,88 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrateCommand(t *testing.T) {
+	database := filepath.Join(t.TempDir(), "migrate")
+	run := func(args ...string) (string, error) {
+		buf := &bytes.Buffer{}
+		c := NewRootCommand()
+		c.SetOut(buf)
+		c.SetArgs(append([]string{"migrate", "--driver", "sqlite", "--database", database}, args...))
+		err := c.Execute()
+		return buf.String(), err
+	}
+
+	output, err := run("dry-run")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "[dry-run] apply 1: create the store tables")
+
+	output, err = run("up", "--to", "1")
+	assert.NoError(t, err)
+	assert.Equal(t, "apply 1: create the store tables\n", output)
+
+	output, err = run("status")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "current version: 1, latest version: 3")
+	assert.Contains(t, output, "2\tpending\t")
+
+	output, err = run("up")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "apply 2:")
+
+	output, err = run("down", "--dry-run")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "[dry-run] revert 3:")
+
+	output, err = run("down", "--to", "0")
+	assert.NoError(t, err)
+	assert.Contains(t, output, "revert 3:")
+	assert.Contains(t, output, "revert 2:")
+	assert.Contains(t, output, "revert 1:")
+
+	output, err = run("down")
+	assert.NoError(t, err)
+	assert.Equal(t, "nothing to revert\n", output)
+
+	c := NewRootCommand()
+	c.SetOut(&bytes.Buffer{})
+	c.SetArgs([]string{"migrate", "status", "--driver", "mysql"})
+	assert.Error(t, c.Execute())
+
+	// the store properties, e.g. the raw DSN, are passed as the server does
+	path := filepath.Join(t.TempDir(), "dsn.db")
+	buf := &bytes.Buffer{}
+	c = NewRootCommand()
+	c.SetOut(buf)
+	c.SetArgs([]string{"migrate", "up", "--driver", "sqlite", "--property", "dsn=file:" + path,
+		"--property", "journalMode=WAL"})
+	assert.NoError(t, c.Execute())
+	assert.Contains(t, buf.String(), "apply 1:")
+	assert.FileExists(t, path)
+
+	c = NewRootCommand()
+	c.SetOut(&bytes.Buffer{})
+	c.SetArgs([]string{"migrate", "status", "--property", "sslMode=fake", "--url", "localhost"})
+	assert.ErrorContains(t, c.Execute(), "sslMode")
+}
@@ -0,0 +1,99 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/linuxsuren/api-testing/pkg/server"
+	"github.com/linuxsuren/api-testing/pkg/testing/remote"
+	"github.com/linuxsuren/atest-ext-store-orm/pkg"
+	"github.com/spf13/cobra"
+)
+
+func newQueryCommand() (c *cobra.Command) {
+	opt := &queryOption{}
+	c = &cobra.Command{
+		Use:   "query <sql>",
+		Short: "Stream the rows of the data query as JSON lines",
+		Long: `Stream the rows of the data query as JSON lines, the rows are written once they are read,
+so the memory does not grow with the result, and there is no max rows guard.
+The page hints of the SQL are honored, and the next page token is printed to stderr.`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: opt.preRunE,
+		RunE:    opt.runE,
+	}
+	flags := c.Flags()
+	flags.BoolVarP(&opt.readOnly, "readonly", "", false, "Reject the SQL statements which might write, e.g. INSERT, DELETE or DROP")
+	opt.addFlags(flags)
+	return
+}
+
+type queryOption struct {
+	dbOption
+}
+
+func (o *queryOption) runE(c *cobra.Command, args []string) (err error) {
+	ctx := remote.WithIncomingStoreContext(c.Context(), o.toStore())
+	streamer := pkg.NewRemoteServer(0, "").(pkg.QueryStreamer)
+
+	out := c.OutOrStdout()
+	var meta *server.DataMeta
+	if meta, err = streamer.StreamQuery(ctx, &server.DataQuery{Sql: args[0]}, func(row *server.Pairs) (err error) {
+		var line []byte
+		if line, err = rowJSON(row); err == nil {
+			_, err = out.Write(append(line, '\n'))
+		}
+		return
+	}); err != nil {
+		return
+	}
+
+	for _, label := range meta.Labels {
+		if label.Key == "_next_page_token" {
+			c.PrintErrf("next page token: %s\n", label.Value)
+		}
+	}
+	return
+}
+
+// rowJSON renders the row as a JSON object in the order of the columns, the NULL values are null
+func rowJSON(row *server.Pairs) (data []byte, err error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+	for i, pair := range row.Data {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		var key, value []byte
+		if key, err = json.Marshal(pair.Key); err != nil {
+			return
+		}
+		if value, err = json.Marshal(pair.Value); err != nil {
+			return
+		}
+		if pair.Description == "null" {
+			value = []byte("null")
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	data = buf.Bytes()
+	return
+}
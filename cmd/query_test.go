@@ -0,0 +1,60 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package cmd
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryCommand(t *testing.T) {
+	database := filepath.Join(t.TempDir(), "query")
+	run := func(sql string, args ...string) (string, string, error) {
+		out, errOut := &bytes.Buffer{}, &bytes.Buffer{}
+		c := NewRootCommand()
+		c.SetOut(out)
+		c.SetErr(errOut)
+		c.SetArgs(append([]string{"query", "--driver", "sqlite", "--database", database, sql}, args...))
+		err := c.Execute()
+		return out.String(), errOut.String(), err
+	}
+
+	output, _, err := run(`CREATE TABLE users (name TEXT, age INTEGER);
+INSERT INTO users VALUES ('alice', 20), ('bob', NULL), ('"carol"', 30)`)
+	assert.NoError(t, err)
+	assert.Empty(t, output)
+
+	output, _, err = run("SELECT name, age FROM users")
+	assert.NoError(t, err)
+	assert.Equal(t, `{"name":"alice","age":"20"}
+{"name":"bob","age":null}
+{"name":"\"carol\"","age":"30"}
+`, output)
+
+	output, errOutput, err := run("/* page_size=2 */ SELECT name FROM users ORDER BY name DESC")
+	assert.NoError(t, err)
+	assert.Equal(t, "{\"name\":\"bob\"}\n{\"name\":\"alice\"}\n", output)
+	assert.Contains(t, errOutput, "next page token: ")
+
+	_, _, err = run("DELETE FROM users", "--readonly")
+	assert.ErrorContains(t, err, "the DELETE statement 1 is not allowed in the read-only mode")
+
+	_, _, err = run("SELECT 1", "SELECT 2")
+	assert.Error(t, err)
+}
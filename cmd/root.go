@@ -16,6 +16,9 @@ limitations under the License.
 package cmd
 
 import (
+	"context"
+	"time"
+
 	ext "github.com/linuxsuren/api-testing/pkg/extension"
 	"github.com/linuxsuren/api-testing/pkg/version"
 	"github.com/linuxsuren/atest-ext-store-orm/pkg"
@@ -30,12 +33,20 @@ func NewRootCommand() (c *cobra.Command) {
 		Use:   opt.GetFullName(),
 		Short: "Storage extension of api-testing",
 		RunE:  opt.runE,
+		PersistentPreRun: func(*cobra.Command, []string) {
+			pkg.EnableSecretReferences(opt.secretReferences)
+			pkg.EnableSecretCommands(opt.secretCommands)
+		},
 	}
 	opt.AddFlags(c.Flags())
 	c.Flags().IntVarP(&opt.historyLimit, "history-limit", "", 1000, "History record items count limit")
+	c.Flags().StringVarP(&opt.timezone, "timezone", "", "", "Default time zone of the history records and query results, e.g. UTC, America/New_York. Use the local time zone if it's empty")
 	c.Flags().BoolVarP(&opt.version, "version", "", false, "Print the version then exit")
+	c.Flags().DurationVarP(&opt.healthCheckInterval, "health-check-interval", "", 30*time.Second, "Interval of pinging the cached connection pools, the unhealthy pools are recreated. Disable it with 0")
+	c.PersistentFlags().BoolVarP(&opt.secretReferences, "secret-references", "", false, "Allow the secret references to take the environment variables and files, e.g. env:DB_PASSWORD")
+	c.PersistentFlags().BoolVarP(&opt.secretCommands, "secret-commands", "", false, "Allow the secret references to take the output of commands, e.g. cmd:cat /run/secrets/password, which also needs --secret-references")
 
-	c.AddCommand(newMCPCommand())
+	c.AddCommand(newMCPCommand(), newMigrateCommand(), newQueryCommand())
 	return
 }
 
@@ -51,13 +62,23 @@ func (o *option) runE(c *cobra.Command, args []string) (err error) {
 		c.Println(version.GetDate())
 		return
 	}
-	remoteServer := pkg.NewRemoteServer(o.historyLimit)
+	ctx := c.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	pkg.StartHealthCheck(ctx, o.healthCheckInterval)
+
+	remoteServer := pkg.NewRemoteServer(o.historyLimit, o.timezone)
 	err = ext.CreateRunner(o.Extension, c, remoteServer)
 	return
 }
 
 type option struct {
 	*ext.Extension
-	historyLimit int
-	version      bool
+	historyLimit        int
+	timezone            string
+	version             bool
+	secretReferences    bool
+	secretCommands      bool
+	healthCheckInterval time.Duration
 }
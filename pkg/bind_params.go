@@ -0,0 +1,214 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// bindParamsComment matches the comment of the bind parameters, e.g. /* params: {"id": 1} */
+var bindParamsComment = regexp.MustCompile(`(?s)/\*\s*params\s*:(.*?)\*/`)
+
+// bindParams are the values of the placeholders, either the positional ones of ? or
+// the named ones of :name and @name
+type bindParams struct {
+	Positional []interface{}
+	Named      map[string]interface{}
+
+	// next is the index of the next positional value
+	next int
+	// used records the bound names
+	used map[string]bool
+}
+
+// parseBindParams reads the bind parameters from the params comment of the SQL, which is a JSON
+// array for the positional placeholders or a JSON object for the named ones. It returns nil
+// if there is no params comment.
+func parseBindParams(sqlText string) (params *bindParams, err error) {
+	matches := bindParamsComment.FindAllStringSubmatch(sqlText, -1)
+	switch len(matches) {
+	case 0:
+		return
+	case 1:
+	default:
+		err = errors.New("only one params comment is allowed")
+		return
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(matches[0][1]))
+	// keep the big integers
+	decoder.UseNumber()
+	var value interface{}
+	if err = decoder.Decode(&value); err == nil && decoder.More() {
+		err = errors.New("unexpected content after the value")
+	}
+	if err != nil {
+		err = fmt.Errorf("invalid params: %w", err)
+		return
+	}
+
+	switch v := value.(type) {
+	case []interface{}:
+		params = &bindParams{Positional: make([]interface{}, 0, len(v))}
+		for _, item := range v {
+			params.Positional = append(params.Positional, bindValue(item))
+		}
+	case map[string]interface{}:
+		params = &bindParams{Named: make(map[string]interface{}, len(v)), used: map[string]bool{}}
+		for name, item := range v {
+			params.Named[name] = bindValue(item)
+		}
+	default:
+		err = errors.New("invalid params: it must be a JSON array or object")
+	}
+	return
+}
+
+// bindValue converts the JSON value to the one supported by the drivers,
+// the arrays and objects are bound as the JSON text
+func bindValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case json.Number:
+		if i, err := v.Int64(); err == nil {
+			return i
+		}
+		f, _ := v.Float64()
+		return f
+	case []interface{}, map[string]interface{}:
+		data, _ := json.Marshal(v)
+		return string(data)
+	}
+	return value
+}
+
+// bindStatements replaces the placeholders of the statements with the native ones of the dialect,
+// e.g. $1 of PostgreSQL or @p1 of SQL Server, and returns the values of each statement.
+// The positional values are consumed by the statements in order. The statements are
+// not changed without the params.
+func bindStatements(statements []string, db *gorm.DB, params *bindParams) (bound []string, args [][]interface{}, err error) {
+	args = make([][]interface{}, len(statements))
+	if params == nil {
+		bound = statements
+		return
+	}
+
+	bound = make([]string, len(statements))
+	for i, statement := range statements {
+		if bound[i], args[i], err = params.bind(statement, db); err != nil {
+			return
+		}
+	}
+
+	if params.next < len(params.Positional) {
+		err = fmt.Errorf("there are %d positional parameters, but only %d placeholders",
+			len(params.Positional), params.next)
+		return
+	}
+	names := make([]string, 0, len(params.Named))
+	for name := range params.Named {
+		if !params.used[name] {
+			names = append(names, name)
+		}
+	}
+	if len(names) > 0 {
+		slices.Sort(names)
+		err = fmt.Errorf("the parameters are not used: %s", strings.Join(names, ", "))
+	}
+	return
+}
+
+// bindLabelStatement returns the bound statement and its values for the labels, e.g. the plan
+// of EXPLAIN. The multiple statements are joined without the values, which are not explained.
+func bindLabelStatement(sqlText string, db *gorm.DB) (statement string, args []interface{}) {
+	statements := splitStatements(sqlText, db.Dialector.Name())
+	params, err := parseBindParams(sqlText)
+	var bound []string
+	var values [][]interface{}
+	if err == nil {
+		bound, values, err = bindStatements(statements, db, params)
+	}
+	if err != nil {
+		// the query fails with the same error, only the params comment is removed
+		statement = strings.TrimSpace(bindParamsComment.ReplaceAllString(sqlText, ""))
+		return
+	}
+
+	if len(bound) == 1 {
+		statement, args = bound[0], values[0]
+	} else {
+		statement = strings.Join(bound, ";\n")
+	}
+	return
+}
+
+// bind replaces the placeholders outside the quotes and comments, and removes the params comment.
+// The named placeholder without a value is kept as it is, e.g. the MySQL user variable @total.
+func (p *bindParams) bind(statement string, db *gorm.DB) (sqlText string, args []interface{}, err error) {
+	opts := splitOptionsOf(db.Dialector.Name())
+	stmt := &gorm.Statement{DB: db}
+	var builder strings.Builder
+	for i := 0; i < len(statement); {
+		end := skippedEnd(statement, i, opts)
+		if end > 0 {
+			// the values do not go to the database with the SQL text
+			if chunk := statement[i : i+end]; bindParamsComment.FindString(chunk) != chunk {
+				builder.WriteString(chunk)
+			}
+			i += end
+			continue
+		}
+
+		end = 1
+		var value interface{}
+		var found bool
+		switch c := statement[i]; {
+		case c == '?' && p.Positional != nil:
+			if p.next >= len(p.Positional) {
+				err = fmt.Errorf("there are only %d positional parameters", len(p.Positional))
+				return
+			}
+			value, found = p.Positional[p.next], true
+			p.next++
+		case (c == ':' || c == '@') && p.Named != nil && !isIdentifierChar(statement, i-1) &&
+			(i == 0 || statement[i-1] != c):
+			// skip the casts and system variables, e.g. id::text and @@VERSION
+			for end < len(statement)-i && isIdentifierChar(statement, i+end) {
+				end++
+			}
+			name := statement[i+1 : i+end]
+			if value, found = p.Named[name]; found {
+				p.used[name] = true
+			}
+		}
+
+		if found {
+			stmt.Vars = append(stmt.Vars, value)
+			db.Dialector.BindVarTo(&builder, stmt, value)
+		} else {
+			builder.WriteString(statement[i : i+end])
+		}
+		i += end
+	}
+	sqlText, args = strings.TrimSpace(builder.String()), stmt.Vars
+	return
+}
@@ -0,0 +1,238 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/linuxsuren/api-testing/pkg/server"
+	atest "github.com/linuxsuren/api-testing/pkg/testing"
+	"github.com/linuxsuren/api-testing/pkg/testing/remote"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/driver/sqlserver"
+	"gorm.io/gorm"
+)
+
+func TestParseBindParams(t *testing.T) {
+	params, err := parseBindParams("SELECT 1 /* page_size=10 */")
+	assert.NoError(t, err)
+	assert.Nil(t, params)
+
+	params, err = parseBindParams(`/* params: [9007199254740993, 1.5, "a*\/b", null, true, {"k": [1]}] */ SELECT ?`)
+	assert.NoError(t, err)
+	assert.Equal(t, []interface{}{int64(9007199254740993), 1.5, "a*/b", nil, true, `{"k":[1]}`}, params.Positional)
+	assert.Nil(t, params.Named)
+
+	params, err = parseBindParams("SELECT :id /*params:{\n\"id\": 1\n}*/")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"id": int64(1)}, params.Named)
+	assert.Nil(t, params.Positional)
+
+	for _, sqlText := range []string{
+		"/* params: [1] */ SELECT ? /* params: [2] */",
+		"/* params: [1 */ SELECT ?",
+		"/* params: [1] [2] */ SELECT ?",
+		"/* params: 1 */ SELECT ?",
+	} {
+		_, err = parseBindParams(sqlText)
+		assert.Error(t, err, sqlText)
+	}
+}
+
+func TestBindStatements(t *testing.T) {
+	dialectors := map[string]gorm.Dialector{
+		DialectorMySQL:     mysql.New(mysql.Config{}),
+		DialectorPostgres:  postgres.New(postgres.Config{}),
+		DialectorSQLServer: sqlserver.New(sqlserver.Config{}),
+	}
+	tests := []struct {
+		name    string
+		dialect string
+		sql     string
+		expect  []string
+		args    [][]interface{}
+		hasErr  bool
+	}{{
+		name:    "without params",
+		dialect: DialectorMySQL,
+		sql:     "SELECT * FROM users WHERE id = ?",
+		expect:  []string{"SELECT * FROM users WHERE id = ?"},
+		args:    [][]interface{}{nil},
+	}, {
+		name:    "positional in quotes",
+		dialect: DialectorMySQL,
+		sql:     `/* params: [1] */ SELECT * FROM users WHERE id = ? AND name = '?' AND note = "it\"s ?" # ?`,
+		expect:  []string{`SELECT * FROM users WHERE id = ? AND name = '?' AND note = "it\"s ?" # ?`},
+		args:    [][]interface{}{{int64(1)}},
+	}, {
+		name:    "positional of multiple statements",
+		dialect: DialectorPostgres,
+		sql:     "/* params: [1, 2, 3] */ SELECT ?; SELECT ?, ?",
+		expect:  []string{"SELECT $1", "SELECT $1, $2"},
+		args:    [][]interface{}{{int64(1)}, {int64(2), int64(3)}},
+	}, {
+		name:    "named with casts and dollar quotes",
+		dialect: DialectorPostgres,
+		sql:     `/* params: {"id": 1, "name": "a"} */ SELECT :id::text, $$:name$$ WHERE id = :id OR name = :name`,
+		expect:  []string{"SELECT $1::text, $$:name$$ WHERE id = $2 OR name = $3"},
+		args:    [][]interface{}{{int64(1), int64(1), "a"}},
+	}, {
+		name:    "named with system variables",
+		dialect: DialectorSQLServer,
+		sql:     `/* params: {"id": 1} */ SELECT @@VERSION, [user:id] FROM users WHERE id = @id`,
+		expect:  []string{"SELECT @@VERSION, [user:id] FROM users WHERE id = @p1"},
+		args:    [][]interface{}{{int64(1)}},
+	}, {
+		name:    "named with user variables",
+		dialect: DialectorMySQL,
+		sql:     `/* params: {"n": 2} */ SET @total = 1; SELECT @total + :n -- :n`,
+		expect:  []string{"SET @total = 1", "SELECT @total + ? -- :n"},
+		args:    [][]interface{}{nil, {int64(2)}},
+	}, {
+		name:    "too few positional params",
+		dialect: DialectorMySQL,
+		sql:     "/* params: [1] */ SELECT ?, ?",
+		hasErr:  true,
+	}, {
+		name:    "too many positional params",
+		dialect: DialectorMySQL,
+		sql:     "/* params: [1, 2] */ SELECT ?",
+		hasErr:  true,
+	}, {
+		name:    "unused named params",
+		dialect: DialectorMySQL,
+		sql:     `/* params: {"id": 1, "nmae": "a"} */ SELECT :id, :name`,
+		hasErr:  true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &gorm.DB{Config: &gorm.Config{Dialector: dialectors[tt.dialect]}}
+			params, err := parseBindParams(tt.sql)
+			assert.NoError(t, err)
+
+			bound, args, err := bindStatements(splitStatements(tt.sql, tt.dialect), db, params)
+			if tt.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, bound)
+				assert.Equal(t, tt.args, args)
+			}
+		})
+	}
+}
+
+func TestBindLabelStatement(t *testing.T) {
+	db := &gorm.DB{Config: &gorm.Config{Dialector: postgres.New(postgres.Config{})}}
+	statement, args := bindLabelStatement("SELECT * FROM users WHERE id = 1", db)
+	assert.Equal(t, "SELECT * FROM users WHERE id = 1", statement)
+	assert.Empty(t, args)
+
+	statement, args = bindLabelStatement(`/* params: {"id": 1} */ SELECT * FROM users WHERE id = :id`, db)
+	assert.Equal(t, "SELECT * FROM users WHERE id = $1", statement)
+	assert.Equal(t, []interface{}{int64(1)}, args)
+
+	statement, args = bindLabelStatement("/* params: [1, 2] */ SELECT ?; SELECT ?", db)
+	assert.Equal(t, "SELECT $1;\nSELECT $1", statement)
+	assert.Empty(t, args)
+
+	statement, args = bindLabelStatement("/* params: [1] */ SELECT ?, ?", db)
+	assert.Equal(t, "SELECT ?, ?", statement)
+	assert.Empty(t, args)
+
+	t.Run("explain the bound statement", func(t *testing.T) {
+		sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		assert.NoError(t, err)
+		defer sqlDB.Close()
+		db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+		assert.NoError(t, err)
+
+		mock.ExpectQuery("explain SELECT * FROM users WHERE id = ?").WithArgs(1).
+			WillReturnRows(sqlmock.NewRows([]string{"id", "type"}).AddRow(1, "const").AddRow(2, "ALL"))
+		mock.ExpectQuery("show variables like 'version'").
+			WillReturnRows(sqlmock.NewRows([]string{"Variable_name", "version"}))
+		statement, args := bindLabelStatement("/* params: [1] */ SELECT * FROM users WHERE id = ?", db)
+		labels := NewCommonDataQuery(GetInnerSQL(DialectorMySQL), db).GetLabels(context.Background(), statement, args...)
+		assert.Equal(t, []*server.Pair{{Key: "sql_type", Value: "const"}}, labels)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestBindParamsQuery(t *testing.T) {
+	t.Run("native placeholders", func(t *testing.T) {
+		sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		assert.NoError(t, err)
+		defer sqlDB.Close()
+		db, err := gorm.Open(postgres.New(postgres.Config{Conn: sqlDB}), &gorm.Config{})
+		assert.NoError(t, err)
+
+		mock.ExpectQuery("SELECT name FROM users WHERE id = $1 AND name <> $2").WithArgs(1, "it's").
+			WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("alice"))
+		result, err := runMultilineSQL(context.Background(),
+			`/* params: [1, "it's"] */ SELECT name FROM users WHERE id = ? AND name <> ?`, db, queryPaging{})
+		assert.NoError(t, err)
+		assert.Equal(t, []*server.Pair{{Key: "name", Value: "alice"}}, result.Items[0].Data)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("sqlite", func(t *testing.T) {
+		store := &atest.Store{
+			Name: t.Name(),
+			URL:  filepath.Join(t.TempDir(), "params.db"),
+			Properties: map[string]string{
+				"driver": driverSQLitePureGo,
+			},
+		}
+		ctx := remote.WithIncomingStoreContext(context.TODO(), store)
+		remoteServer := NewRemoteServer(10, "")
+
+		_, err := remoteServer.Query(ctx, &server.DataQuery{
+			Sql: `/* params: {"name": "O'Brien", "age": 30} */
+CREATE TABLE users (name TEXT, age INTEGER);
+INSERT INTO users VALUES (:name, :age)`,
+		})
+		assert.NoError(t, err)
+
+		result, err := remoteServer.Query(ctx, &server.DataQuery{
+			Sql: `/* params: ["O'Brien"] */ SELECT name, age FROM users WHERE name = ?`,
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []*server.Pair{{Key: "name", Value: "O'Brien"}, {Key: "age", Value: "30"}}, result.Items[0].Data)
+
+		mcpServer := NewMcpServer(store)
+		_, _, err = mcpServer.Query(context.TODO(), nil, DBQuery{
+			SQL:    "INSERT INTO users VALUES (@name, @age)",
+			Params: map[string]any{"name": "a */ b", "age": 1},
+		})
+		assert.NoError(t, err)
+		mcpResult, _, err := mcpServer.Query(context.TODO(), nil, DBQuery{
+			SQL:    "SELECT age FROM users WHERE name = ?",
+			Params: []any{"a */ b"},
+		})
+		assert.NoError(t, err)
+		row := mcpResult.StructuredContent.(*server.DataQueryResult).Items[0].Data
+		assert.Equal(t, "age", row[0].Key)
+		assert.Equal(t, "1", row[0].Value)
+
+		_, err = remoteServer.Query(ctx, &server.DataQuery{Sql: `/* params: [1] */ SELECT ?, ?`})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,70 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	chdriver "github.com/ClickHouse/clickhouse-go/v2"
+	"gorm.io/driver/clickhouse"
+	"gorm.io/gorm"
+)
+
+// clickHouseDSN generates the DSN of the native protocol, the default port is 9000
+func clickHouseDSN(user, password, address, database string, params url.Values) string {
+	if !strings.Contains(address, ":") {
+		address = fmt.Sprintf("%s:%d", address, 9000)
+	}
+	return (&url.URL{
+		Scheme:   "clickhouse",
+		User:     url.UserPassword(user, password),
+		Host:     address,
+		Path:     "/" + database,
+		RawQuery: params.Encode(),
+	}).String()
+}
+
+// newClickHouseDialector creates the dialector with the TLS config,
+// the DSN of clickhouse-go is not able to carry the certificates.
+func newClickHouseDialector(dsn string, opts tlsOptions) (dialector gorm.Dialector, err error) {
+	if !opts.enabled() {
+		dialector = clickhouse.Open(dsn)
+		return
+	}
+
+	var options *chdriver.Options
+	if options, err = chdriver.ParseDSN(dsn); err != nil {
+		return
+	}
+
+	var host string
+	if len(options.Addr) > 0 {
+		if host, _, err = net.SplitHostPort(options.Addr[0]); err != nil {
+			return
+		}
+	}
+	if options.TLS, err = opts.config(host); err != nil {
+		return
+	}
+
+	dialector = clickhouse.New(clickhouse.Config{
+		Conn: chdriver.OpenDB(options),
+	})
+	return
+}
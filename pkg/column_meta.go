@@ -0,0 +1,140 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// encodingBase64 marks the binary column, whose values are encoded by base64
+const encodingBase64 = "base64"
+
+// nullDescription is the description of the pairs whose values are NULL,
+// which tells them apart from the string "null"
+const nullDescription = "null"
+
+// columnMeta describes a column of the result set, the optional fields
+// are empty when the driver does not support them
+type columnMeta struct {
+	Name         string `json:"name"`
+	DatabaseType string `json:"databaseType,omitempty"`
+	Nullable     *bool  `json:"nullable,omitempty"`
+	Precision    *int64 `json:"precision,omitempty"`
+	Scale        *int64 `json:"scale,omitempty"`
+	Length       *int64 `json:"length,omitempty"`
+	// Kind is the Go kind of the scan type, e.g. int64, float64, string or slice
+	Kind     string `json:"kind,omitempty"`
+	Encoding string `json:"encoding,omitempty"`
+}
+
+func newColumnMetas(names []string, columnTypes []*sql.ColumnType) (columns []*columnMeta) {
+	columns = make([]*columnMeta, len(names))
+	for i, name := range names {
+		column := &columnMeta{Name: name}
+		if i < len(columnTypes) {
+			columnType := columnTypes[i]
+			column.DatabaseType = strings.ToUpper(columnType.DatabaseTypeName())
+			if nullable, ok := columnType.Nullable(); ok {
+				column.Nullable = &nullable
+			}
+			if precision, scale, ok := columnType.DecimalSize(); ok {
+				column.Precision, column.Scale = &precision, &scale
+			}
+			if length, ok := columnType.Length(); ok {
+				column.Length = &length
+			}
+			if scanType := columnType.ScanType(); scanType != nil {
+				column.Kind = scanType.Kind().String()
+			}
+		}
+		if isBinaryType(column.DatabaseType) {
+			column.Encoding = encodingBase64
+		}
+		columns[i] = column
+	}
+	return
+}
+
+func columnNames(columns []*columnMeta) (names []string) {
+	names = make([]string, len(columns))
+	for i, column := range columns {
+		names[i] = column.Name
+	}
+	return
+}
+
+var binaryTypes = []string{"BLOB", "TINYBLOB", "MEDIUMBLOB", "LONGBLOB", "BINARY", "VARBINARY", "BYTEA", "IMAGE"}
+
+func isBinaryType(databaseType string) bool {
+	baseType, _, _ := strings.Cut(databaseType, "(")
+	for _, binaryType := range binaryTypes {
+		if baseType == binaryType {
+			return true
+		}
+	}
+	return false
+}
+
+// formatColumn renders the value of the column, and the description of the pair which marks the
+// NULL value, or the value which is encoded by base64 because it is not valid UTF-8.
+// The values of the binary columns are always encoded by base64, which is the encoding of the column.
+func formatColumn(ctx context.Context, val interface{}, column columnMeta) (text, description string) {
+	switch v := val.(type) {
+	case nil:
+		return "null", nullDescription
+	case []byte:
+		switch {
+		case len(v) == 16 && column.DatabaseType == "UUID":
+			text = uuid.UUID(v).String()
+		case column.Encoding == encodingBase64:
+			text = base64.StdEncoding.EncodeToString(v)
+		case !utf8.Valid(v):
+			text, description = base64.StdEncoding.EncodeToString(v), encodingBase64
+		default:
+			text = string(v)
+		}
+	case string:
+		text = v
+	case int, uint64, uint32, int32, int64:
+		text = fmt.Sprintf("%d", v)
+	case float32:
+		text = strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		text = strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		text = v.In(timezoneFromContext(ctx)).String()
+	case bool:
+		text = strconv.FormatBool(v)
+	case []int, []uint64, []uint32, []int32, []int64, []float32, []float64, []string:
+		text = fmt.Sprintf("%v", v)
+	default:
+		if value := reflect.ValueOf(v); (value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface) && value.IsNil() {
+			return "null", nullDescription
+		}
+		text = formatValue(ctx, v)
+	}
+	return
+}
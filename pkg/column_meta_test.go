@@ -0,0 +1,192 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/linuxsuren/api-testing/pkg/server"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestFormatColumn(t *testing.T) {
+	ctx := context.TODO()
+	tests := []struct {
+		name        string
+		val         interface{}
+		column      columnMeta
+		expect      string
+		description string
+	}{{
+		name:        "NULL",
+		val:         nil,
+		expect:      "null",
+		description: nullDescription,
+	}, {
+		name:   "string null",
+		val:    "null",
+		expect: "null",
+	}, {
+		name:        "nil pointer",
+		val:         (*string)(nil),
+		expect:      "null",
+		description: nullDescription,
+	}, {
+		name:   "float64 precision",
+		val:    math.Nextafter(0.3, 1),
+		expect: "0.30000000000000004",
+	}, {
+		name:   "float32",
+		val:    float32(1.1),
+		expect: "1.1",
+	}, {
+		name:   "big float",
+		val:    1e21,
+		expect: "1000000000000000000000",
+	}, {
+		name:   "big integer",
+		val:    new(big.Int).Lsh(big.NewInt(1), 100),
+		expect: "1267650600228229401496703205376",
+	}, {
+		name:   "uint64",
+		val:    uint64(18446744073709551615),
+		expect: "18446744073709551615",
+	}, {
+		name:   "decimal text",
+		val:    []byte("12345678901234567890.123456789"),
+		column: columnMeta{DatabaseType: "DECIMAL"},
+		expect: "12345678901234567890.123456789",
+	}, {
+		name:   "JSON",
+		val:    []byte(`{"a":[1,2]}`),
+		column: columnMeta{DatabaseType: "JSON"},
+		expect: `{"a":[1,2]}`,
+	}, {
+		name:   "UUID",
+		val:    []byte{0x8c, 0x2b, 0x5b, 0x34, 0x1b, 0x6e, 0x4f, 0x4f, 0x9d, 0x2a, 0x3f, 0x5f, 0x3c, 0x0e, 0x9a, 0x11},
+		column: columnMeta{DatabaseType: "UUID"},
+		expect: "8c2b5b34-1b6e-4f4f-9d2a-3f5f3c0e9a11",
+	}, {
+		name:   "binary column",
+		val:    []byte("abc"),
+		column: columnMeta{DatabaseType: "VARBINARY", Encoding: encodingBase64},
+		expect: "YWJj",
+	}, {
+		name:        "invalid UTF-8",
+		val:         []byte{0xff, 0xfe},
+		expect:      "//4=",
+		description: encodingBase64,
+	}, {
+		name:   "bool",
+		val:    true,
+		expect: "true",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, description := formatColumn(ctx, tt.val, tt.column)
+			assert.Equal(t, tt.expect, text)
+			assert.Equal(t, tt.description, description)
+		})
+	}
+}
+
+func TestColumnMetas(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+	assert.NoError(t, err)
+
+	mock.ExpectQuery("SELECT * FROM t").WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+		mock.NewColumn("price").OfType("decimal", "").Nullable(false).WithPrecisionAndScale(10, 2),
+		mock.NewColumn("name").OfType("varchar", "").Nullable(true).WithLength(64),
+		mock.NewColumn("avatar").OfType("blob", []byte{}).Nullable(true),
+	).AddRow([]byte("12.50"), nil, []byte("abc")))
+
+	result, err := sqlQuery(context.TODO(), "SELECT * FROM t", db)
+	assert.NoError(t, err)
+	assert.Equal(t, []*server.Pair{
+		{Key: "price", Value: "12.50"},
+		{Key: "name", Value: "null", Description: nullDescription},
+		{Key: "avatar", Value: "YWJj"},
+	}, result.Items[0].Data)
+
+	var columns []map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(labelOf(result.Meta.Labels, "_column_types")), &columns))
+	assert.Equal(t, []map[string]interface{}{{
+		"name": "price", "databaseType": "DECIMAL", "nullable": false, "precision": float64(10), "scale": float64(2),
+		"kind": "string",
+	}, {
+		"name": "name", "databaseType": "VARCHAR", "nullable": true, "length": float64(64), "kind": "string",
+	}, {
+		"name": "avatar", "databaseType": "BLOB", "nullable": true, "kind": "slice", "encoding": "base64",
+	}}, columns)
+	assert.Equal(t, `["price","name","avatar"]`, labelOf(result.Meta.Labels, "_columns"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestColumnMetasOfInvalidUTF8(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+	assert.NoError(t, err)
+
+	// only the invalid value is encoded, the encoding of the text column is not changed
+	mock.ExpectQuery("SELECT name FROM t").WillReturnRows(sqlmock.NewRowsWithColumnDefinition(
+		mock.NewColumn("name").OfType("varchar", ""),
+	).AddRow([]byte("a")).AddRow([]byte{0xff, 0xfe}).AddRow([]byte("b")))
+
+	result, err := sqlQuery(context.TODO(), "SELECT name FROM t", db)
+	assert.NoError(t, err)
+	assert.Equal(t, []*server.Pairs{
+		{Data: []*server.Pair{{Key: "name", Value: "a"}}},
+		{Data: []*server.Pair{{Key: "name", Value: "//4=", Description: encodingBase64}}},
+		{Data: []*server.Pair{{Key: "name", Value: "b"}}},
+	}, result.Items)
+	assert.NotContains(t, labelOf(result.Meta.Labels, "_column_types"), "encoding")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestColumnMetasOfSQLite(t *testing.T) {
+	db, err := openMemoryDB()
+	assert.NoError(t, err)
+	assert.NoError(t, db.Exec("CREATE TABLE t (id INTEGER, score REAL, data BLOB)").Error)
+	assert.NoError(t, db.Exec("INSERT INTO t VALUES (9007199254740993, 0.1, x'00ff')").Error)
+
+	result, err := sqlQuery(context.TODO(), "SELECT * FROM t", db)
+	assert.NoError(t, err)
+	assert.Equal(t, []*server.Pair{
+		{Key: "id", Value: "9007199254740993"},
+		{Key: "score", Value: "0.1"},
+		{Key: "data", Value: "AP8="},
+	}, result.Items[0].Data)
+
+	var columns []columnMeta
+	assert.NoError(t, json.Unmarshal([]byte(labelOf(result.Meta.Labels, "_column_types")), &columns))
+	if assert.Len(t, columns, 3) {
+		assert.Equal(t, "INTEGER", columns[0].DatabaseType)
+		assert.Equal(t, "REAL", columns[1].DatabaseType)
+		assert.Equal(t, encodingBase64, columns[2].Encoding)
+	}
+}
@@ -0,0 +1,560 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultMaxPools    = 32
+	defaultPoolIdleTTL = 30 * time.Minute
+
+	healthCheckTimeout  = 5 * time.Second
+	healthHistorySize   = 10
+	maxReconnectBackoff = 5 * time.Minute
+)
+
+// dbPool is the shared connection manager of all the gRPC handlers
+var dbPool = newConnManager(defaultMaxPools, defaultPoolIdleTTL)
+
+// ConnManagerStats represents the statistics of the connection manager
+type ConnManagerStats struct {
+	Pools      int
+	Hits       uint64
+	Misses     uint64
+	Evictions  uint64
+	Reconnects uint64
+}
+
+func (s ConnManagerStats) String() string {
+	return fmt.Sprintf("pools=%d, hits=%d, misses=%d, evictions=%d, reconnects=%d",
+		s.Pools, s.Hits, s.Misses, s.Evictions, s.Reconnects)
+}
+
+// HealthCheck represents the result of one health check of a pool
+type HealthCheck struct {
+	Time    time.Time
+	Latency time.Duration
+	Error   string
+}
+
+// PoolHealth represents the health history of a pool
+type PoolHealth struct {
+	Healthy     bool
+	LastSuccess time.Time
+	LastError   string
+	LastErrorAt time.Time
+	Latency     time.Duration
+	Failures    int
+	Reconnects  int
+	// History holds the latest checks, the oldest first
+	History []HealthCheck
+}
+
+func (h PoolHealth) String() string {
+	status := "healthy"
+	if !h.Healthy {
+		status = fmt.Sprintf("unhealthy after %d failure(s)", h.Failures)
+	}
+	msg := fmt.Sprintf("%s, latency=%s", status, h.Latency)
+	if !h.LastSuccess.IsZero() {
+		msg += fmt.Sprintf(", last success=%s", h.LastSuccess.Format(time.RFC3339))
+	}
+	if h.LastError != "" {
+		msg += fmt.Sprintf(", last error=%q at %s", h.LastError, h.LastErrorAt.Format(time.RFC3339))
+	}
+	if h.Reconnects > 0 {
+		msg += fmt.Sprintf(", reconnects=%d", h.Reconnects)
+	}
+	return msg
+}
+
+type pooledDB struct {
+	key      string
+	db       *gorm.DB
+	open     func() (*gorm.DB, error)
+	lastUsed time.Time
+	health   PoolHealth
+	// pinned pools are never evicted, e.g. an in-memory database whose data is gone with the pool
+	pinned bool
+	// nextReconnect is the earliest time to recreate the unhealthy pool
+	nextReconnect     time.Time
+	reconnectFailures int
+}
+
+// poolRef counts the callers using a *gorm.DB
+type poolRef struct {
+	key   string
+	users int
+	// retired means the pool is evicted or replaced, and it is closed once it is not in use
+	retired bool
+}
+
+// connManager caches one *gorm.DB per (store, database) pair.
+// The least recently used pools are evicted when the cache is full,
+// and the pools idle longer than idleTTL are evicted on the next access.
+// Evicted pools are closed once the callers which got them released them.
+// The health check pings the cached pools, and recreates the unhealthy
+// ones with an exponential backoff.
+type connManager struct {
+	mu          sync.Mutex
+	group       singleflight.Group
+	items       map[string]*list.Element
+	refs        map[*gorm.DB]*poolRef
+	lru         *list.List
+	maxPools    int
+	idleTTL     time.Duration
+	stats       ConnManagerStats
+	now         func() time.Time
+	healthCheck sync.Once
+}
+
+func newConnManager(maxPools int, idleTTL time.Duration) *connManager {
+	return &connManager{
+		items:    make(map[string]*list.Element),
+		refs:     make(map[*gorm.DB]*poolRef),
+		lru:      list.New(),
+		maxPools: maxPools,
+		idleTTL:  idleTTL,
+		now:      time.Now,
+	}
+}
+
+// connKey builds the cache key of a pool. The credentials are hashed
+// to avoid keeping them in plaintext.
+func connKey(storeName, database string, credentials ...string) string {
+	hash := sha256.Sum256([]byte(strings.Join(credentials, "\x00")))
+	return fmt.Sprintf("%s/%s/%s", storeName, database, hex.EncodeToString(hash[:8]))
+}
+
+// Get returns the cached pool of the key, or opens a new one, along with the function
+// which releases the pool once the caller is done with it.
+// Concurrent calls for the same key share a single open.
+func (m *connManager) Get(key string, open func() (*gorm.DB, error)) (db *gorm.DB, release func(), err error) {
+	return m.get(key, false, open)
+}
+
+// GetPinned is like Get, but the pool is never evicted, e.g. an in-memory database
+func (m *connManager) GetPinned(key string, open func() (*gorm.DB, error)) (db *gorm.DB, release func(), err error) {
+	return m.get(key, true, open)
+}
+
+func (m *connManager) get(key string, pinned bool, open func() (*gorm.DB, error)) (db *gorm.DB, release func(), err error) {
+	for {
+		if db, release = m.lookup(key); db != nil {
+			return
+		}
+
+		var val interface{}
+		if val, err, _ = m.group.Do(key, func() (interface{}, error) {
+			// another caller might have opened it before we entered
+			if cached := m.cached(key); cached != nil {
+				return cached, nil
+			}
+
+			newDB, openErr := open()
+			if openErr != nil {
+				if newDB != nil {
+					closePools([]*pooledDB{{key: key, db: newDB}})
+				}
+				return nil, openErr
+			}
+			m.add(key, newDB, open, pinned)
+			return newDB, nil
+		}); err != nil {
+			return
+		}
+		// the pool might be evicted before it is acquired, then try again
+		if db, release = m.acquire(val.(*gorm.DB)); db != nil {
+			return
+		}
+	}
+}
+
+func (m *connManager) lookup(key string) (db *gorm.DB, release func()) {
+	m.mu.Lock()
+	evicted := m.evictExpiredLocked()
+	if elem, ok := m.items[key]; ok {
+		item := elem.Value.(*pooledDB)
+		item.lastUsed = m.now()
+		m.lru.MoveToFront(elem)
+		db, release = m.acquireLocked(item.db)
+		m.stats.Hits++
+	} else {
+		m.stats.Misses++
+	}
+	m.mu.Unlock()
+
+	closePools(evicted)
+	return
+}
+
+func (m *connManager) cached(key string) (db *gorm.DB) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if elem, ok := m.items[key]; ok {
+		db = elem.Value.(*pooledDB).db
+	}
+	return
+}
+
+func (m *connManager) acquire(db *gorm.DB) (*gorm.DB, func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.acquireLocked(db)
+}
+
+// acquireLocked counts a user of the pool, it returns nil if the pool is retired
+func (m *connManager) acquireLocked(db *gorm.DB) (acquired *gorm.DB, release func()) {
+	ref, ok := m.refs[db]
+	if !ok || ref.retired {
+		return
+	}
+	ref.users++
+
+	once := sync.Once{}
+	acquired, release = db, func() {
+		once.Do(func() {
+			m.release(db)
+		})
+	}
+	return
+}
+
+func (m *connManager) release(db *gorm.DB) {
+	m.mu.Lock()
+	var closing []*pooledDB
+	if ref, ok := m.refs[db]; ok {
+		if ref.users--; ref.retired && ref.users == 0 {
+			delete(m.refs, db)
+			closing = []*pooledDB{{key: ref.key, db: db}}
+		}
+	}
+	m.mu.Unlock()
+
+	closePools(closing)
+}
+
+// retireLocked returns the pool to close if it is not in use, otherwise the last release closes it
+func (m *connManager) retireLocked(key string, db *gorm.DB) []*pooledDB {
+	if ref, ok := m.refs[db]; ok {
+		if ref.retired = true; ref.users > 0 {
+			return nil
+		}
+		delete(m.refs, db)
+	}
+	return []*pooledDB{{key: key, db: db}}
+}
+
+func (m *connManager) add(key string, db *gorm.DB, open func() (*gorm.DB, error), pinned bool) {
+	m.mu.Lock()
+	var evicted []*pooledDB
+	if elem, ok := m.items[key]; ok {
+		evicted = append(evicted, m.removeLocked(elem)...)
+	}
+	m.refs[db] = &poolRef{key: key}
+	added := m.lru.PushFront(&pooledDB{
+		key:      key,
+		db:       db,
+		open:     open,
+		lastUsed: m.now(),
+		health:   PoolHealth{Healthy: true},
+		pinned:   pinned,
+	})
+	m.items[key] = added
+	for m.maxPools > 0 && m.lru.Len() > m.maxPools {
+		elem := m.lru.Back()
+		for elem != nil && (elem == added || elem.Value.(*pooledDB).pinned) {
+			elem = elem.Prev()
+		}
+		if elem == nil {
+			// the pinned pools are kept even if the cache is full
+			break
+		}
+		evicted = append(evicted, m.removeLocked(elem)...)
+	}
+	m.mu.Unlock()
+
+	closePools(evicted)
+}
+
+func (m *connManager) evictExpiredLocked() (evicted []*pooledDB) {
+	if m.idleTTL <= 0 {
+		return
+	}
+	now := m.now()
+	for elem := m.lru.Back(); elem != nil; {
+		item := elem.Value.(*pooledDB)
+		if now.Sub(item.lastUsed) < m.idleTTL {
+			break
+		}
+		prev := elem.Prev()
+		if !item.pinned {
+			evicted = append(evicted, m.removeLocked(elem)...)
+		}
+		elem = prev
+	}
+	return
+}
+
+// removeLocked removes the pool from the cache, and returns it if it can be closed right now
+func (m *connManager) removeLocked(elem *list.Element) []*pooledDB {
+	item := m.lru.Remove(elem).(*pooledDB)
+	delete(m.items, item.key)
+	m.stats.Evictions++
+	return m.retireLocked(item.key, item.db)
+}
+
+// Stats returns a snapshot of the statistics
+func (m *connManager) Stats() (stats ConnManagerStats) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stats = m.stats
+	stats.Pools = m.lru.Len()
+	return
+}
+
+// Health returns a snapshot of the health history of the pool
+func (m *connManager) Health(key string) (health PoolHealth, ok bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var elem *list.Element
+	if elem, ok = m.items[key]; ok {
+		health = elem.Value.(*pooledDB).health
+		health.History = append([]HealthCheck(nil), health.History...)
+	}
+	return
+}
+
+// Record adds a check result into the health history of the pool
+func (m *connManager) Record(key string, latency time.Duration, checkErr error) (health PoolHealth) {
+	m.mu.Lock()
+	if elem, ok := m.items[key]; ok {
+		m.recordLocked(elem.Value.(*pooledDB), latency, checkErr)
+	}
+	m.mu.Unlock()
+	health, _ = m.Health(key)
+	return
+}
+
+func (m *connManager) recordLocked(item *pooledDB, latency time.Duration, checkErr error) {
+	check := HealthCheck{Time: m.now(), Latency: latency}
+	health := &item.health
+	health.Latency = latency
+	if checkErr == nil {
+		health.Healthy = true
+		health.Failures = 0
+		health.LastSuccess = check.Time
+		item.nextReconnect = time.Time{}
+		item.reconnectFailures = 0
+	} else {
+		check.Error = checkErr.Error()
+		health.Healthy = false
+		health.Failures++
+		health.LastError = check.Error
+		health.LastErrorAt = check.Time
+	}
+	health.History = append(health.History, check)
+	if len(health.History) > healthHistorySize {
+		health.History = health.History[len(health.History)-healthHistorySize:]
+	}
+}
+
+// StartHealthCheck pings the cached pools of all the stores every interval until the context is done
+func StartHealthCheck(ctx context.Context, interval time.Duration) {
+	dbPool.StartHealthCheck(ctx, interval)
+}
+
+// StartHealthCheck starts the health check loop, only the first call takes effect
+func (m *connManager) StartHealthCheck(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	m.healthCheck.Do(func() {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					m.CheckHealth(ctx, interval)
+				}
+			}
+		}()
+	})
+}
+
+// CheckHealth pings all the cached pools, and recreates the unhealthy pools once their backoff elapsed.
+// The backoff starts from the interval, and doubles after each failed reconnect.
+func (m *connManager) CheckHealth(ctx context.Context, interval time.Duration) {
+	m.mu.Lock()
+	items := make([]*pooledDB, 0, m.lru.Len())
+	dbs := make([]*gorm.DB, 0, m.lru.Len())
+	releases := make([]func(), 0, m.lru.Len())
+	for elem := m.lru.Front(); elem != nil; elem = elem.Next() {
+		item := elem.Value.(*pooledDB)
+		// the pool is in use during the check, so it is not closed by an eviction
+		if db, release := m.acquireLocked(item.db); db != nil {
+			items = append(items, item)
+			dbs = append(dbs, db)
+			releases = append(releases, release)
+		}
+	}
+	m.mu.Unlock()
+
+	for i, item := range items {
+		m.checkHealth(ctx, item, dbs[i], interval)
+		releases[i]()
+	}
+}
+
+func (m *connManager) checkHealth(ctx context.Context, item *pooledDB, db *gorm.DB, interval time.Duration) {
+	start := m.now()
+	pingErr := pingDB(ctx, db)
+	latency := m.now().Sub(start)
+
+	m.mu.Lock()
+	if !m.cachedLocked(item, db) {
+		m.mu.Unlock()
+		return
+	}
+	m.recordLocked(item, latency, pingErr)
+	// a pinned pool keeps its data in memory, which is gone once it is reopened
+	reconnect := pingErr != nil && item.open != nil && !item.pinned && !m.now().Before(item.nextReconnect)
+	m.mu.Unlock()
+
+	if pingErr != nil {
+		log.Printf("connection pool %q is unhealthy: %v", item.key, pingErr)
+	}
+	if reconnect {
+		m.reconnect(ctx, item, db, interval)
+	}
+}
+
+// reconnect replaces the pool with a new one, the old pool is closed once it is not in use.
+// It shares the singleflight group with Get, so a key is never opened twice at the same time.
+func (m *connManager) reconnect(ctx context.Context, item *pooledDB, oldDB *gorm.DB, interval time.Duration) {
+	_, _, _ = m.group.Do(item.key, func() (interface{}, error) {
+		m.replace(ctx, item, oldDB, interval)
+		return m.cached(item.key), nil
+	})
+}
+
+func (m *connManager) replace(ctx context.Context, item *pooledDB, oldDB *gorm.DB, interval time.Duration) {
+	m.mu.Lock()
+	cached := m.cachedLocked(item, oldDB)
+	m.mu.Unlock()
+	if !cached {
+		// the pool is evicted or replaced already
+		return
+	}
+
+	newDB, err := item.open()
+	start := m.now()
+	if err == nil {
+		err = pingDB(ctx, newDB)
+	}
+	latency := m.now().Sub(start)
+
+	m.mu.Lock()
+	cached = m.cachedLocked(item, oldDB)
+	if err != nil || !cached {
+		if err != nil && cached {
+			backoff := interval << min(item.reconnectFailures, 16)
+			item.reconnectFailures++
+			if backoff <= 0 || backoff > maxReconnectBackoff {
+				backoff = maxReconnectBackoff
+			}
+			item.nextReconnect = m.now().Add(backoff)
+		}
+		m.mu.Unlock()
+
+		if err != nil {
+			log.Printf("failed to reconnect connection pool %q: %v", item.key, err)
+		}
+		if newDB != nil {
+			closePools([]*pooledDB{{key: item.key, db: newDB}})
+		}
+		return
+	}
+	item.db = newDB
+	m.refs[newDB] = &poolRef{key: item.key}
+	item.health.Reconnects++
+	m.recordLocked(item, latency, nil)
+	m.stats.Reconnects++
+	closing := m.retireLocked(item.key, oldDB)
+	m.mu.Unlock()
+
+	log.Printf("reconnected connection pool %q", item.key)
+	closePools(closing)
+}
+
+// cachedLocked reports whether the pool is still cached with the same *gorm.DB
+func (m *connManager) cachedLocked(item *pooledDB, db *gorm.DB) bool {
+	elem, ok := m.items[item.key]
+	return ok && elem.Value.(*pooledDB) == item && item.db == db
+}
+
+func pingDB(ctx context.Context, db *gorm.DB) (err error) {
+	var sqlDB *sql.DB
+	if sqlDB, err = db.DB(); err != nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+	err = sqlDB.PingContext(ctx)
+	return
+}
+
+// Close closes all the cached pools, the ones in use are closed once they are released
+func (m *connManager) Close() {
+	m.mu.Lock()
+	var evicted []*pooledDB
+	for elem := m.lru.Back(); elem != nil; elem = m.lru.Back() {
+		evicted = append(evicted, m.removeLocked(elem)...)
+	}
+	m.mu.Unlock()
+
+	closePools(evicted)
+}
+
+func closePools(items []*pooledDB) {
+	for _, item := range items {
+		log.Printf("close connection pool %q", item.key)
+		closeReplicas(item.db)
+		if sqlDB, err := item.db.DB(); err == nil {
+			releaseMemoryConn(sqlDB)
+			if err = sqlDB.Close(); err != nil {
+				log.Printf("failed to close connection pool %q: %v", item.key, err)
+			}
+		}
+	}
+}
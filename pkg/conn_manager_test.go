@@ -0,0 +1,411 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func openMemoryDB() (*gorm.DB, error) {
+	return gorm.Open(openSQLite("sqlite", ":memory:"), &gorm.Config{})
+}
+
+// getReleased gets the pool, and releases it right away
+func getReleased(manager *connManager, key string, open func() (*gorm.DB, error)) (db *gorm.DB, err error) {
+	var release func()
+	if db, release, err = manager.Get(key, open); err == nil {
+		release()
+	}
+	return
+}
+
+func isClosed(t *testing.T, db *gorm.DB) bool {
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	return sqlDB.Ping() != nil
+}
+
+func TestConnManager(t *testing.T) {
+	t.Run("cache by key", func(t *testing.T) {
+		manager := newConnManager(2, 0)
+		defer manager.Close()
+
+		first, err := getReleased(manager, "a", openMemoryDB)
+		assert.NoError(t, err)
+		second, err := getReleased(manager, "a", openMemoryDB)
+		assert.NoError(t, err)
+		assert.Same(t, first, second)
+
+		other, err := getReleased(manager, "b", openMemoryDB)
+		assert.NoError(t, err)
+		assert.NotSame(t, first, other)
+		assert.Equal(t, ConnManagerStats{Pools: 2, Hits: 1, Misses: 2}, manager.Stats())
+	})
+
+	t.Run("open failed", func(t *testing.T) {
+		manager := newConnManager(2, 0)
+		_, err := getReleased(manager, "a", func() (*gorm.DB, error) {
+			return nil, errors.New("fake")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 0, manager.Stats().Pools)
+	})
+
+	t.Run("open once under concurrency", func(t *testing.T) {
+		manager := newConnManager(2, 0)
+		defer manager.Close()
+
+		var opened int32
+		wg := sync.WaitGroup{}
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				_, err := getReleased(manager, "a", func() (*gorm.DB, error) {
+					atomic.AddInt32(&opened, 1)
+					time.Sleep(10 * time.Millisecond)
+					return openMemoryDB()
+				})
+				assert.NoError(t, err)
+			}()
+		}
+		wg.Wait()
+		assert.Equal(t, int32(1), opened)
+	})
+
+	t.Run("evict the least recently used", func(t *testing.T) {
+		manager := newConnManager(2, 0)
+		defer manager.Close()
+
+		a, _ := getReleased(manager, "a", openMemoryDB)
+		b, _ := getReleased(manager, "b", openMemoryDB)
+		_, _ = getReleased(manager, "a", openMemoryDB)
+		_, _ = getReleased(manager, "c", openMemoryDB)
+
+		assert.False(t, isClosed(t, a))
+		assert.True(t, isClosed(t, b))
+		assert.Equal(t, uint64(1), manager.Stats().Evictions)
+	})
+
+	t.Run("evict the idle pools", func(t *testing.T) {
+		now := time.Now()
+		manager := newConnManager(2, time.Minute)
+		manager.now = func() time.Time {
+			return now
+		}
+		defer manager.Close()
+
+		a, _ := getReleased(manager, "a", openMemoryDB)
+		now = now.Add(2 * time.Minute)
+		b, _ := getReleased(manager, "b", openMemoryDB)
+
+		assert.True(t, isClosed(t, a))
+		assert.False(t, isClosed(t, b))
+		assert.Equal(t, 1, manager.Stats().Pools)
+	})
+
+	t.Run("evict the pool in use", func(t *testing.T) {
+		manager := newConnManager(1, 0)
+		defer manager.Close()
+
+		a, release, err := manager.Get("a", openMemoryDB)
+		assert.NoError(t, err)
+		rows, err := a.Raw("WITH RECURSIVE n(i) AS (SELECT 1 UNION ALL SELECT i + 1 FROM n WHERE i < 100) SELECT i FROM n").Rows()
+		assert.NoError(t, err)
+
+		// the query is still running when its pool is evicted
+		_, _ = getReleased(manager, "b", openMemoryDB)
+		assert.Equal(t, uint64(1), manager.Stats().Evictions)
+		assert.False(t, isClosed(t, a))
+		var count int
+		for ; rows.Next(); count++ {
+		}
+		assert.NoError(t, rows.Err())
+		assert.NoError(t, rows.Close())
+		assert.Equal(t, 100, count)
+
+		release()
+		assert.True(t, isClosed(t, a))
+		// releasing twice does nothing
+		release()
+	})
+
+	t.Run("keep the pinned pools", func(t *testing.T) {
+		now := time.Now()
+		manager := newConnManager(1, time.Minute)
+		manager.now = func() time.Time {
+			return now
+		}
+		defer manager.Close()
+
+		pinned, release, err := manager.GetPinned("memory", openMemoryDB)
+		assert.NoError(t, err)
+		release()
+		now = now.Add(2 * time.Minute)
+		b, _ := getReleased(manager, "b", openMemoryDB)
+		_, _ = getReleased(manager, "c", openMemoryDB)
+
+		assert.False(t, isClosed(t, pinned))
+		assert.True(t, isClosed(t, b))
+		assert.Equal(t, 2, manager.Stats().Pools)
+	})
+
+	t.Run("key does not contain the password", func(t *testing.T) {
+		key := connKey("store", "db", "mysql", "localhost", "root", "secret")
+		assert.NotContains(t, key, "secret")
+		assert.NotEqual(t, key, connKey("store", "db", "mysql", "localhost", "root", "other"))
+	})
+}
+
+func TestConnManagerHealth(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("record the history", func(t *testing.T) {
+		manager := newConnManager(2, 0)
+		defer manager.Close()
+
+		_, err := getReleased(manager, "a", openMemoryDB)
+		assert.NoError(t, err)
+		for i := 0; i < healthHistorySize+2; i++ {
+			manager.Record("a", time.Millisecond, nil)
+		}
+		health := manager.Record("a", 2*time.Millisecond, errors.New("fake"))
+		assert.False(t, health.Healthy)
+		assert.Equal(t, 1, health.Failures)
+		assert.Equal(t, "fake", health.LastError)
+		assert.Equal(t, 2*time.Millisecond, health.Latency)
+		assert.False(t, health.LastSuccess.IsZero())
+		assert.Len(t, health.History, healthHistorySize)
+		assert.Equal(t, "fake", health.History[healthHistorySize-1].Error)
+		assert.Contains(t, health.String(), "unhealthy after 1 failure(s)")
+
+		health = manager.Record("a", time.Millisecond, nil)
+		assert.True(t, health.Healthy)
+		assert.Equal(t, 0, health.Failures)
+		assert.Equal(t, "fake", health.LastError)
+
+		_, ok := manager.Health("b")
+		assert.False(t, ok)
+	})
+
+	t.Run("reconnect the unhealthy pool", func(t *testing.T) {
+		manager := newConnManager(2, 0)
+		defer manager.Close()
+
+		var opened int32
+		open := func() (*gorm.DB, error) {
+			atomic.AddInt32(&opened, 1)
+			return openMemoryDB()
+		}
+		first, err := getReleased(manager, "a", open)
+		assert.NoError(t, err)
+
+		manager.CheckHealth(ctx, time.Second)
+		health, _ := manager.Health("a")
+		assert.True(t, health.Healthy)
+		assert.Equal(t, int32(1), opened)
+
+		sqlDB, err := first.DB()
+		assert.NoError(t, err)
+		assert.NoError(t, sqlDB.Close())
+
+		manager.CheckHealth(ctx, time.Second)
+		second, err := getReleased(manager, "a", open)
+		assert.NoError(t, err)
+		assert.NotSame(t, first, second)
+		assert.False(t, isClosed(t, second))
+		assert.Equal(t, int32(2), opened)
+
+		health, _ = manager.Health("a")
+		assert.True(t, health.Healthy)
+		assert.Equal(t, 1, health.Reconnects)
+		assert.NotEmpty(t, health.LastError)
+		assert.Len(t, health.History, 3)
+		assert.Equal(t, uint64(1), manager.Stats().Reconnects)
+	})
+
+	t.Run("reconnect the pool in use", func(t *testing.T) {
+		manager := newConnManager(2, 0)
+		defer manager.Close()
+
+		sqlDB, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		assert.NoError(t, err)
+		var opened int32
+		open := func() (*gorm.DB, error) {
+			if atomic.AddInt32(&opened, 1) == 1 {
+				return gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+			}
+			return openMemoryDB()
+		}
+		mock.ExpectPing()
+		first, release, err := manager.Get("a", open)
+		assert.NoError(t, err)
+
+		mock.ExpectPing().WillReturnError(errors.New("fake"))
+		manager.CheckHealth(ctx, time.Second)
+		second, err := getReleased(manager, "a", open)
+		assert.NoError(t, err)
+		assert.NotSame(t, first, second)
+
+		// the old pool is not closed until it is released
+		mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"1"}).AddRow(1))
+		var one int
+		assert.NoError(t, first.Raw("SELECT 1").Scan(&one).Error)
+		assert.Equal(t, 1, one)
+		mock.ExpectClose()
+		release()
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("reconnect with backoff", func(t *testing.T) {
+		now := time.Now()
+		manager := newConnManager(2, 0)
+		manager.now = func() time.Time {
+			return now
+		}
+		defer manager.Close()
+
+		var opened int32
+		db, err := getReleased(manager, "a", func() (*gorm.DB, error) {
+			if atomic.AddInt32(&opened, 1) > 1 {
+				return nil, errors.New("fake")
+			}
+			return openMemoryDB()
+		})
+		assert.NoError(t, err)
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+		assert.NoError(t, sqlDB.Close())
+
+		manager.CheckHealth(ctx, time.Second)
+		assert.Equal(t, int32(2), opened)
+
+		// wait for the backoff before the next reconnect
+		manager.CheckHealth(ctx, time.Second)
+		assert.Equal(t, int32(2), opened)
+		now = now.Add(time.Second)
+		manager.CheckHealth(ctx, time.Second)
+		assert.Equal(t, int32(3), opened)
+
+		// the backoff doubles after each failure
+		now = now.Add(time.Second)
+		manager.CheckHealth(ctx, time.Second)
+		assert.Equal(t, int32(3), opened)
+		now = now.Add(time.Second)
+		manager.CheckHealth(ctx, time.Second)
+		assert.Equal(t, int32(4), opened)
+
+		health, _ := manager.Health("a")
+		assert.False(t, health.Healthy)
+		assert.Equal(t, 5, health.Failures)
+		assert.Equal(t, 0, health.Reconnects)
+	})
+
+	t.Run("do not reconnect the pinned pool", func(t *testing.T) {
+		manager := newConnManager(2, 0)
+		defer manager.Close()
+
+		var opened int32
+		pinned, release, err := manager.GetPinned("memory", func() (*gorm.DB, error) {
+			atomic.AddInt32(&opened, 1)
+			return openMemoryDB()
+		})
+		assert.NoError(t, err)
+		release()
+		sqlDB, err := pinned.DB()
+		assert.NoError(t, err)
+		assert.NoError(t, sqlDB.Close())
+
+		manager.CheckHealth(ctx, time.Second)
+		assert.Equal(t, int32(1), opened)
+		health, _ := manager.Health("memory")
+		assert.False(t, health.Healthy)
+		assert.Equal(t, 0, health.Reconnects)
+	})
+
+	t.Run("reconnect and get share the open", func(t *testing.T) {
+		manager := newConnManager(1, 0)
+		defer manager.Close()
+
+		var opened, opening, maxOpening int32
+		reconnecting, resume := make(chan struct{}), make(chan struct{})
+		open := func() (*gorm.DB, error) {
+			defer atomic.AddInt32(&opening, -1)
+			if n := atomic.AddInt32(&opening, 1); n > atomic.LoadInt32(&maxOpening) {
+				atomic.StoreInt32(&maxOpening, n)
+			}
+			if atomic.AddInt32(&opened, 1) == 2 {
+				close(reconnecting)
+				<-resume
+			}
+			return openMemoryDB()
+		}
+		db, err := getReleased(manager, "a", open)
+		assert.NoError(t, err)
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+		assert.NoError(t, sqlDB.Close())
+
+		checked := make(chan struct{})
+		go func() {
+			defer close(checked)
+			manager.CheckHealth(ctx, time.Second)
+		}()
+		<-reconnecting
+
+		// the pool is evicted during the reconnect, then it is opened again
+		_, err = getReleased(manager, "b", openMemoryDB)
+		assert.NoError(t, err)
+		got := make(chan *gorm.DB)
+		go func() {
+			db, err := getReleased(manager, "a", open)
+			assert.NoError(t, err)
+			got <- db
+		}()
+		time.Sleep(20 * time.Millisecond)
+		close(resume)
+		<-checked
+
+		assert.False(t, isClosed(t, <-got))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&maxOpening))
+		assert.Equal(t, int32(3), atomic.LoadInt32(&opened))
+	})
+
+	t.Run("start the loop", func(t *testing.T) {
+		manager := newConnManager(2, 0)
+		defer manager.Close()
+		_, err := getReleased(manager, "a", openMemoryDB)
+		assert.NoError(t, err)
+
+		loopCtx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		manager.StartHealthCheck(loopCtx, 10*time.Millisecond)
+		assert.Eventually(t, func() bool {
+			health, _ := manager.Health("a")
+			return len(health.History) > 0
+		}, time.Second, 10*time.Millisecond)
+	})
+}
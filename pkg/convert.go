@@ -121,16 +121,21 @@ func ConvertToDBTestSuite(suite *remote.TestSuite) (result *TestSuite) {
 	return
 }
 
-func ConvertToDBHistoryTestResult(historyTestResult *server.HistoryTestResult) (result *HistoryTestResult) {
+// ConvertToDBHistoryTestResult converts the history to the database model,
+// the ID and history suite name are generated in the given location.
+func ConvertToDBHistoryTestResult(historyTestResult *server.HistoryTestResult, loc *time.Location) (result *HistoryTestResult) {
 	result = &HistoryTestResult{
 		Message: historyTestResult.Message,
 		Error:   historyTestResult.Error,
 	}
 	if historyTestResult.CreateTime != nil {
-		id := fmt.Sprintf("%s_%s_%s", historyTestResult.CreateTime.AsTime().Local().Format("2006-01-02T15:04:05.999999999"), historyTestResult.Data.SuiteName, historyTestResult.Data.CaseName)
-		result.ID = id
-		result.CreateTime = historyTestResult.CreateTime.AsTime().Local().Format("2006-01-02T15:04:05.999999999")
-		result.HistorySuiteName = historyTestResult.CreateTime.AsTime().Local().Format("2006-1-2")
+		if loc == nil {
+			loc = time.Local
+		}
+		createTime := historyTestResult.CreateTime.AsTime()
+		result.ID = fmt.Sprintf("%s_%s_%s", createTime.In(loc).Format(historyTimeLayout), historyTestResult.Data.SuiteName, historyTestResult.Data.CaseName)
+		result.CreateTime = createTime.UTC()
+		result.HistorySuiteName = createTime.In(loc).Format("2006-1-2")
 	}
 	if historyTestResult.Data != nil {
 		result.Param = pairToJSON(historyTestResult.Data.SuiteParam)
@@ -170,14 +175,10 @@ func ConvertToDBHistoryTestResult(historyTestResult *server.HistoryTestResult) (
 }
 
 func ConvertToRemoteHistoryTestResult(historyTestResult *HistoryTestResult) (result *server.HistoryTestResult) {
-	createTime, err := time.Parse("2006-01-02T15:04:05.999999999", historyTestResult.CreateTime)
-	if err != nil {
-		fmt.Println("Error parsing time:", err)
-	}
 	result = &server.HistoryTestResult{
 		Message:    historyTestResult.Message,
 		Error:      historyTestResult.Error,
-		CreateTime: timestamppb.New(createTime),
+		CreateTime: timestamppb.New(historyTestResult.CreateTime),
 	}
 	TestCaseResult := &server.TestCaseResult{
 		StatusCode: historyTestResult.StatusCode,
@@ -215,10 +216,6 @@ func ConvertToGRPCHistoryTestSuite(historyTestResult *HistoryTestResult) (result
 }
 
 func ConvertToGRPCHistoryTestCase(historyTestResult *HistoryTestResult) (result *server.HistoryTestCase) {
-	createTime, err := time.Parse("2006-01-02T15:04:05.999999999", historyTestResult.CreateTime)
-	if err != nil {
-		fmt.Println("Error parsing time:", err)
-	}
 	result = &server.HistoryTestCase{
 		ID:               historyTestResult.ID,
 		SuiteName:        historyTestResult.SuiteName,
@@ -226,7 +223,7 @@ func ConvertToGRPCHistoryTestCase(historyTestResult *HistoryTestResult) (result
 		SuiteApi:         historyTestResult.SuiteAPI,
 		SuiteParam:       jsonToPair(historyTestResult.Param),
 		HistorySuiteName: historyTestResult.HistorySuiteName,
-		CreateTime:       timestamppb.New(createTime),
+		CreateTime:       timestamppb.New(historyTestResult.CreateTime),
 		HistoryHeader:    jsonToPair(historyTestResult.HistoryHeader),
 
 		SuiteSpec: &server.APISpec{
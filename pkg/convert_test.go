@@ -152,7 +152,7 @@ func TestConvertTestSuite(t *testing.T) {
 
 func TestConvertToDBHistoryTestResult(t *testing.T) {
 	t.Run("without testcaseResult and historyTestcase", func(t *testing.T) {
-		result := pkg.ConvertToDBHistoryTestResult(&server.HistoryTestResult{})
+		result := pkg.ConvertToDBHistoryTestResult(&server.HistoryTestResult{}, time.UTC)
 		assert.Equal(t, &pkg.HistoryTestResult{}, result)
 	})
 
@@ -186,7 +186,7 @@ func TestConvertToDBHistoryTestResult(t *testing.T) {
 					Output:     "Test output",
 				},
 			},
-		})
+		}, time.UTC)
 		assert.Equal(t, &pkg.HistoryTestResult{
 			StatusCode:       200,
 			Body:             "Test body",
@@ -209,7 +209,6 @@ func TestConvertToDBHistoryTestResult(t *testing.T) {
 }
 
 var now = time.Now().UTC()
-var nowString = now.Format("2006-01-02T15:04:05.999999999")
 
 func TestConvertToRemoteHistoryTestResult(t *testing.T) {
 	assert.Equal(t, &server.HistoryTestResult{
@@ -241,7 +240,7 @@ func TestConvertToRemoteHistoryTestResult(t *testing.T) {
 			},
 		},
 	}, pkg.ConvertToRemoteHistoryTestResult(&pkg.HistoryTestResult{
-		CreateTime: nowString,
+		CreateTime: now,
 		Body:       "body",
 		Output:     "output",
 		Header:     sampleJSONMap,
@@ -265,7 +264,7 @@ func TestConvertToGRPCHistoryTestSuite(t *testing.T) {
 			},
 		},
 	}, pkg.ConvertToGRPCHistoryTestSuite(&pkg.HistoryTestResult{
-		CreateTime: nowString,
+		CreateTime: now,
 		SuiteName:  "name",
 		Body:       "Test Body",
 		SpecKind:   "kind",
@@ -284,7 +283,7 @@ func TestConvertToGRPCHistoryTestCase(t *testing.T) {
 		},
 		Response: &server.Response{},
 	}, pkg.ConvertToGRPCHistoryTestCase(&pkg.HistoryTestResult{
-		CreateTime: nowString,
+		CreateTime: now,
 		SuiteName:  "name",
 		Body:       "Test Body",
 		SpecKind:   "kind",
@@ -18,25 +18,38 @@ package pkg
 import (
 	"context"
 	"database/sql"
+	"encoding"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"reflect"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 	"sync"
 	"time"
 
 	"github.com/linuxsuren/api-testing/pkg/server"
+	"github.com/linuxsuren/api-testing/pkg/testing/remote"
 	"gorm.io/gorm"
 )
 
 func (s *dbserver) Query(ctx context.Context, query *server.DataQuery) (result *server.DataQueryResult, err error) {
 	var db *gorm.DB
 	var dbQuery DataQuery
-	if dbQuery, err = s.getClientWithDatabase(ctx, query.Key); err != nil {
+	var release func()
+	if dbQuery, release, err = s.getClientWithDatabase(ctx, query.Key); err != nil {
 		return
 	}
+	defer release()
+
+	var loc *time.Location
+	if loc, err = loadTimezone(remote.GetStoreFromContext(ctx).Properties, s.defaultTimezone); err != nil {
+		return
+	}
+	ctx = withTimezone(ctx, loc)
 
 	db = dbQuery.GetClient()
 
@@ -47,6 +60,7 @@ func (s *dbserver) Query(ctx context.Context, query *server.DataQuery) (result *
 	}
 
 	wg := sync.WaitGroup{}
+	var tableLabels []*server.Pair
 
 	wg.Add(1)
 	go func() {
@@ -69,12 +83,21 @@ func (s *dbserver) Query(ctx context.Context, query *server.DataQuery) (result *
 		}
 
 		var queryTableErr error
-		if result.Meta.Tables, queryTableErr = dbQuery.GetTables(ctx, result.Meta.CurrentDatabase); err != nil {
+		if result.Meta.Tables, queryTableErr = dbQuery.GetTables(ctx, result.Meta.CurrentDatabase); queryTableErr != nil {
 			log.Printf("failed to query tables: %v\n", queryTableErr)
 		}
+
+		if kindsQuery, ok := dbQuery.(TableKindsQuery); ok {
+			if tableLabels, queryTableErr = kindsQuery.GetTableKinds(ctx, result.Meta.CurrentDatabase); queryTableErr != nil {
+				log.Printf("failed to query table kinds: %v\n", queryTableErr)
+			}
+		}
 	}()
 
-	defer wg.Wait()
+	defer func() {
+		wg.Wait()
+		result.Meta.Labels = append(result.Meta.Labels, tableLabels...)
+	}()
 	// query data
 	if query.Sql == "" {
 		return
@@ -82,10 +105,26 @@ func (s *dbserver) Query(ctx context.Context, query *server.DataQuery) (result *
 
 	query.Sql = dbQuery.GetInnerSQL().ToNativeSQL(query.Sql)
 
+	var paging queryPaging
+	if paging, err = parseQueryPaging(remote.GetStoreFromContext(ctx).Properties, query.Sql); err != nil {
+		return
+	}
+	// the goroutines above share ctx, so the timeout goes to another variable
+	queryCtx, cancel, err := withQueryTimeout(ctx, remote.GetStoreFromContext(ctx).Properties, query.Sql)
+	if err != nil {
+		return
+	}
+	defer cancel()
+	if queryCtx, err = withReadOnlyProperty(queryCtx, remote.GetStoreFromContext(ctx).Properties); err != nil {
+		return
+	}
+
+	// the labels explain the bound statement, the params comment is not valid SQL
+	labelSQL, labelArgs := bindLabelStatement(query.Sql, db)
 	wg.Add(1)
 	go func() {
 		defer wg.Done()
-		result.Meta.Labels = dbQuery.GetLabels(ctx, query.Sql)
+		result.Meta.Labels = dbQuery.GetLabels(queryCtx, labelSQL, labelArgs...)
 		result.Meta.Labels = append(result.Meta.Labels, &server.Pair{
 			Key:   "_native_sql",
 			Value: query.Sql,
@@ -94,7 +133,9 @@ func (s *dbserver) Query(ctx context.Context, query *server.DataQuery) (result *
 
 	var dataResult *server.DataQueryResult
 	now := time.Now()
-	if dataResult, err = runMultilineSQL(ctx, query.Sql, db); err == nil {
+	// only the statements of the user are cancelled on the server side, not the metadata queries above
+	runCtx := withServerCancelDriver(queryCtx, storeDriver(remote.GetStoreFromContext(ctx).Properties))
+	if dataResult, err = runMultilineSQL(runCtx, query.Sql, db, paging); err == nil && dataResult != nil {
 		result.Items = dataResult.Items
 		result.Meta.Duration = time.Since(now).String()
 
@@ -106,58 +147,349 @@ func (s *dbserver) Query(ctx context.Context, query *server.DataQuery) (result *
 	return
 }
 
-func runMultilineSQL(ctx context.Context, multilineSQL string, db *gorm.DB) (result *server.DataQueryResult, err error) {
-	lines := strings.Split(multilineSQL, ";")
-	for _, line := range lines {
-		if strings.TrimSpace(line) == "" {
-			continue
+// QueryStreamer streams the rows of a data query instead of collecting them into memory
+type QueryStreamer interface {
+	StreamQuery(ctx context.Context, query *server.DataQuery, send func(*server.Pairs) error) (*server.DataMeta, error)
+}
+
+// StreamQuery runs the data query like Query, but sends the rows one by one, so the memory does not
+// grow with the result. The page hints are honored, and there is no max rows guard.
+func (s *dbserver) StreamQuery(ctx context.Context, query *server.DataQuery, send func(*server.Pairs) error) (meta *server.DataMeta, err error) {
+	var dbQuery DataQuery
+	var release func()
+	if dbQuery, release, err = s.getClientWithDatabase(ctx, query.Key); err != nil {
+		return
+	}
+	defer release()
+
+	properties := remote.GetStoreFromContext(ctx).Properties
+	var loc *time.Location
+	if loc, err = loadTimezone(properties, s.defaultTimezone); err != nil {
+		return
+	}
+	ctx = withTimezone(ctx, loc)
+
+	sqlText := dbQuery.GetInnerSQL().ToNativeSQL(query.Sql)
+	var paging queryPaging
+	if paging, err = parseQueryPaging(properties, sqlText); err != nil {
+		return
+	}
+	paging.MaxRows = 0
+	var cancel context.CancelFunc
+	if ctx, cancel, err = withQueryTimeout(ctx, properties, sqlText); err != nil {
+		return
+	}
+	defer cancel()
+	if ctx, err = withReadOnlyProperty(ctx, properties); err != nil {
+		return
+	}
+
+	ctx = withServerCancelDriver(ctx, storeDriver(properties))
+
+	meta = &server.DataMeta{CurrentDatabase: query.Key}
+	now := time.Now()
+	if meta.Labels, err = streamMultilineSQL(ctx, sqlText, dbQuery.GetClient(), paging, send); err == nil {
+		meta.Duration = time.Since(now).String()
+		meta.Labels = append(meta.Labels, &server.Pair{Key: "_native_sql", Value: sqlText})
+	}
+	return
+}
+
+// resultSet describes the result of one statement, its rows are Items[Offset:Offset+Rows]
+type resultSet struct {
+	SQL         string        `json:"sql"`
+	Columns     []string      `json:"columns"`
+	ColumnTypes []*columnMeta `json:"columnTypes"`
+	Offset      int           `json:"offset"`
+	Rows        int           `json:"rows"`
+	Duration    string        `json:"duration"`
+	Truncated   bool          `json:"truncated,omitempty"`
+	HasMore     bool          `json:"hasMore,omitempty"`
+}
+
+// runMultilineSQL runs the statements one by one, and returns the rows of all the statements
+func runMultilineSQL(ctx context.Context, multilineSQL string, db *gorm.DB, paging queryPaging) (result *server.DataQueryResult, err error) {
+	result = &server.DataQueryResult{
+		Data:  []*server.Pair{},
+		Items: make([]*server.Pairs, 0),
+		Meta:  &server.DataMeta{},
+	}
+	if result.Meta.Labels, err = streamMultilineSQL(ctx, multilineSQL, db, paging, func(row *server.Pairs) error {
+		result.Items = append(result.Items, row)
+		return nil
+	}); err == nil && result.Meta.Labels == nil {
+		// there is no statement
+		result = nil
+	}
+	return
+}
+
+// streamMultilineSQL runs the statements one by one, and sends the rows in the page, which counts the rows of
+// all the statements. The label _result_sets describes the result set of each statement, and the label _columns
+// has the columns of all the result sets. The label _next_page_token is the page_token hint of the next page,
+// and the label _truncated means some rows are cut by the max rows or the page which cannot be continued.
+// Only the read-only statements are paginated, the page continues from the statement and the sort keys of the last
+// row in the previous page, so the statements before it are not run again.
+func streamMultilineSQL(ctx context.Context, multilineSQL string, db *gorm.DB, paging queryPaging,
+	send func(*server.Pairs) error) (labels []*server.Pair, err error) {
+	var dialect string
+	if db != nil {
+		dialect = db.Dialector.Name()
+	}
+	statements := splitStatements(multilineSQL, dialect)
+	if len(statements) == 0 {
+		return
+	}
+	readOnlyErr := checkReadOnly(statements, dialect)
+	if isReadOnly(ctx) && readOnlyErr != nil {
+		err = readOnlyErr
+		return
+	}
+	cursor := paging.Cursor
+	if cursor != nil {
+		if readOnlyErr != nil {
+			// the writes before the page would run again
+			err = fmt.Errorf("the %s hint is only for the read-only queries: %w", hintPageToken, readOnlyErr)
+			return
+		} else if cursor.Statement >= len(statements) {
+			err = fmt.Errorf("invalid %s hint: there are only %d statements", hintPageToken, len(statements))
+			return
 		}
-		if result, err = sqlQuery(ctx, line, db); err != nil {
+	}
+	pageable := paging.PageSize > 0 && readOnlyErr == nil
+	var params *bindParams
+	if params, err = parseBindParams(multilineSQL); err != nil {
+		return
+	}
+	var bound []string
+	var args [][]interface{}
+	if bound, args, err = bindStatements(statements, db, params); err != nil {
+		return
+	}
+
+	resultSets := make([]resultSet, 0, len(statements))
+	columns := []*columnMeta{}
+	var sent int
+	var next *pageCursor
+	var truncated bool
+	limit := paging.limit()
+	first := 0
+	if cursor != nil {
+		first = cursor.Statement
+	}
+	for i := first; i < len(statements); i++ {
+		if pageable && limit > 0 && sent >= limit {
+			// the rest statements are in the next pages
+			next = &pageCursor{Statement: i}
+			break
+		}
+
+		sqlText, sqlArgs := bound[i], args[i]
+		var order []cursorKey
+		if cursor != nil && i == cursor.Statement && len(cursor.Keys) > 0 {
+			order = cursor.Keys
+		} else if pageable {
+			order = probeOrder(ctx, bound[i], args[i], dialect, db)
+		}
+		if order != nil {
+			_, unordered := parseOrderBy(bound[i], dialect)
+			if sqlText, sqlArgs, err = keysetStatement(unordered, sqlArgs, order, db); err != nil {
+				return
+			}
+		}
+
+		set := resultSet{SQL: statements[i], Offset: sent}
+		now := time.Now()
+		var more bool
+		var lastRow, nextRow []interface{}
+		if set.ColumnTypes, err = streamRows(ctx, sqlText, sqlArgs, db, func(row *server.Pairs, values []interface{}) error {
+			if limit > 0 && sent+set.Rows >= limit {
+				more, nextRow = true, values
+				return errStopRows
+			}
+			set.Rows++
+			lastRow = values
+			return send(row)
+		}); err != nil {
+			if len(statements) > 1 {
+				err = fmt.Errorf("failed to run statement %d: %w", i+1, err)
+			}
 			return
 		}
+		set.Duration = time.Since(now).String()
+		set.Columns = columnNames(set.ColumnTypes)
+		sent += set.Rows
+
+		for _, column := range set.ColumnTypes {
+			if !slices.Contains(columnNames(columns), column.Name) {
+				columns = append(columns, column)
+			}
+		}
+		if more {
+			if pageable {
+				next = nextCursor(order, i, set.Columns, lastRow, nextRow)
+			}
+			set.HasMore = next != nil
+			set.Truncated = next == nil
+			truncated = truncated || set.Truncated
+		}
+		resultSets = append(resultSets, set)
+		if next != nil {
+			break
+		}
+	}
+
+	labels = columnLabels(columns)
+	if data, jsonErr := json.Marshal(resultSets); jsonErr == nil {
+		labels = append(labels, &server.Pair{Key: "_result_sets", Value: string(data)})
+	}
+	if next != nil {
+		labels = append(labels, &server.Pair{Key: "_next_page_token", Value: paging.nextPageToken(*next)})
+	}
+	if truncated {
+		labels = append(labels, &server.Pair{Key: "_truncated", Value: "true"})
 	}
 	return
 }
 
-func sqlQuery(ctx context.Context, sqlText string, db *gorm.DB) (result *server.DataQueryResult, err error) {
-	fmt.Println("execute sql:", sqlText)
-	var rows *sql.Rows
-	if rows, err = db.Raw(sqlText).Rows(); err != nil {
+// probeOrder returns the sort keys to paginate the statement, which come from its columns,
+// so the statement runs once without any row before it is paginated
+func probeOrder(ctx context.Context, statement string, args []interface{}, dialect string, db *gorm.DB) (order []cursorKey) {
+	if !canPaginate(statement, dialect) {
 		return
 	}
-	defer func() {
-		if rows != nil {
-			rows.Close()
+	_, unordered := parseOrderBy(statement, dialect)
+	columns, err := streamRows(ctx, "SELECT * FROM (\n"+unordered+"\n) atest_page WHERE 1 = 0", args, db,
+		func(*server.Pairs, []interface{}) error {
+			return nil
+		})
+	if err != nil {
+		log.Printf("failed to get the columns to paginate: %v", err)
+		return
+	}
+	order = pageOrder(statement, dialect, columns)
+	return
+}
+
+// nextCursor returns the cursor after the last row of the page, which binds the sort keys until the first one
+// that tells the last row apart from the next row. The cursor is nil if the statement cannot be continued,
+// e.g. it has no sort keys, the two rows are the same by all the keys, or a value cannot be bound again.
+func nextCursor(order []cursorKey, index int, columns []string, lastRow, nextRow []interface{}) (cursor *pageCursor) {
+	if len(lastRow) != len(columns) || len(nextRow) != len(columns) {
+		return
+	}
+	// the keys after the one which tells the rows apart only sort the rows
+	keys := make([]cursorKey, len(order))
+	for i, key := range order {
+		keys[i] = cursorKey{Column: key.Column, Desc: key.Desc, Nulls: key.Nulls}
+	}
+	for i, key := range keys {
+		column := slices.Index(columns, key.Column)
+		if column < 0 {
+			return
 		}
-	}()
+		var ok bool
+		if keys[i], ok = key.bind(lastRow[column]); !ok {
+			return
+		}
+		if next, ok := key.bind(nextRow[column]); !ok || next != keys[i] {
+			cursor = &pageCursor{Statement: index, Keys: keys}
+			return
+		}
+	}
+	return
+}
 
+func sqlQuery(ctx context.Context, sqlText string, db *gorm.DB, args ...interface{}) (result *server.DataQueryResult, err error) {
 	result = &server.DataQueryResult{
 		Data:  []*server.Pair{},
 		Items: make([]*server.Pairs, 0),
 		Meta:  &server.DataMeta{},
 	}
 
+	var columns []*columnMeta
+	if columns, err = streamRows(ctx, sqlText, args, db, func(row *server.Pairs, _ []interface{}) error {
+		result.Items = append(result.Items, row)
+		return nil
+	}); err != nil {
+		return
+	}
+	if columns != nil {
+		result.Meta.Labels = columnLabels(columns)
+	}
+	return
+}
+
+// columnLabels returns the label _columns of the column names, and the label _column_types
+// of the types, nullability, precision, scale, length and Go kind of the columns
+func columnLabels(columns []*columnMeta) (labels []*server.Pair) {
+	if columnsData, colsErr := json.Marshal(columnNames(columns)); colsErr == nil {
+		labels = append(labels, &server.Pair{
+			Key:   "_columns",
+			Value: string(columnsData),
+		})
+	}
+	if typesData, typesErr := json.Marshal(columns); typesErr == nil {
+		labels = append(labels, &server.Pair{
+			Key:   "_column_types",
+			Value: string(typesData),
+		})
+	}
+	return
+}
+
+// errStopRows stops streaming the rows without an error
+var errStopRows = errors.New("stop rows")
+
+// streamRows runs the query with the values of the placeholders, and sends the rows one by one
+// along with their scanned values
+func streamRows(ctx context.Context, sqlText string, args []interface{}, db *gorm.DB,
+	send func(row *server.Pairs, values []interface{}) error) (columns []*columnMeta, err error) {
+	log.Printf("execute sql: %s", sqlText)
+	err = withServerCancel(ctx, db, func(tx *gorm.DB) error {
+		return withReadOnlyTx(ctx, tx, func(tx *gorm.DB) (err error) {
+			columns, err = scanRows(ctx, sqlText, args, tx, send)
+			return
+		})
+	})
+	if errors.Is(err, errStopRows) {
+		err = nil
+	}
+	return
+}
+
+// scanRows runs the query with the context of db, and converts the rows to pairs
+func scanRows(ctx context.Context, sqlText string, args []interface{}, db *gorm.DB,
+	send func(row *server.Pairs, values []interface{}) error) (columns []*columnMeta, err error) {
+	var rows *sql.Rows
+	tx := db.Raw(sqlText)
+	// the placeholders are native already, so the values are bound without rewriting the SQL
+	tx.Statement.Vars = args
+	if rows, err = tx.Rows(); err != nil {
+		return
+	}
+	defer func() {
+		if rows != nil {
+			rows.Close()
+		}
+	}()
+
 	if rows == nil {
-		if rows, err = db.ConnPool.QueryContext(ctx, sqlText); err != nil {
+		if rows, err = db.Statement.ConnPool.QueryContext(ctx, sqlText, args...); err != nil {
 			return
 		} else if rows == nil {
-			fmt.Println("no rows found")
+			log.Println("no rows found")
 			return
 		}
 	}
 
-	var columns []string
-	columns, err = rows.Columns()
-	if err != nil {
+	var names []string
+	if names, err = rows.Columns(); err != nil {
 		return
 	}
-
-	if columnsData, colsErr := json.Marshal(columns); colsErr == nil {
-		result.Meta.Labels = append(result.Meta.Labels, &server.Pair{
-			Key:   "_columns",
-			Value: string(columnsData),
-		})
-	}
+	// the types are optional, ignore the error as some drivers do not support it
+	columnTypes, _ := rows.ColumnTypes()
+	columns = newColumnMetas(names, columnTypes)
 
 	for rows.Next() {
 		// Create a slice of interface{}'s to represent each column,
@@ -169,63 +501,123 @@ func sqlQuery(ctx context.Context, sqlText string, db *gorm.DB) (result *server.
 		}
 
 		// Scan the result into the column pointers...
-		if err := rows.Scan(columnsPointers...); err != nil {
-			return nil, err
+		if err = rows.Scan(columnsPointers...); err != nil {
+			return
 		}
 
-		// Create our map, and retrieve the value for each column from the pointers slice,
-		// storing it in the map with the name of the column as the key.
-		for i, colName := range columns {
-			rowData := &server.Pair{}
-			val := columnsData[i]
-
-			rowData.Key = colName
-			switch v := val.(type) {
-			case []byte:
-				rowData.Value = string(v)
-			case string:
-				rowData.Value = v
-			case int, uint64, uint32, int32, int64:
-				rowData.Value = fmt.Sprintf("%d", v)
-			case float32, float64:
-				rowData.Value = fmt.Sprintf("%f", v)
-			case time.Time:
-				rowData.Value = v.String()
-			case bool:
-				rowData.Value = fmt.Sprintf("%t", v)
-			case nil:
-				rowData.Value = "null"
-			case []int, []uint64, []uint32, []int32, []int64:
-				rowData.Value = fmt.Sprintf("%v", v)
-			case []float32, []float64:
-				rowData.Value = fmt.Sprintf("%v", v)
-			case []string:
-				rowData.Value = fmt.Sprintf("%v", v)
-			default:
-				fmt.Println("column", colName, "type", reflect.TypeOf(v))
-			}
-
-			// Append the map to our slice of maps.
-			result.Data = append(result.Data, rowData)
+		// Retrieve the value for each column from the pointers slice,
+		// storing it in the pairs with the name of the column as the key.
+		data := make([]*server.Pair, 0, len(columns))
+		for i, column := range columns {
+			rowData := &server.Pair{Key: column.Name}
+			rowData.Value, rowData.Description = formatColumn(ctx, columnsData[i], *column)
+			data = append(data, rowData)
+		}
+		if err = send(&server.Pairs{Data: data}, columnsData); err != nil {
+			return
 		}
-		result.Items = append(result.Items, &server.Pairs{
-			Data: result.Data,
-		})
 	}
+	// the cancelled query stops the iteration without an error
+	err = rows.Err()
 	return
 }
 
+// formatValue converts the driver specific values to readable ones, such as
+// the UUID, Decimal, Array, Map and Tuple of ClickHouse, or the LIST, STRUCT,
+// MAP and HUGEINT of DuckDB
+func formatValue(ctx context.Context, val interface{}) string {
+	if text, ok := formatDuckDBValue(val); ok {
+		return text
+	}
+
+	switch v := val.(type) {
+	case time.Time:
+		return v.In(timezoneFromContext(ctx)).String()
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	value := reflect.ValueOf(val)
+	switch value.Kind() {
+	case reflect.Pointer, reflect.Interface:
+		if value.IsNil() {
+			return "null"
+		}
+		return formatValue(ctx, value.Elem().Interface())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10)
+	case reflect.Float32:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 32)
+	case reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, 64)
+	case reflect.String:
+		return value.String()
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		if data, err := json.Marshal(toJSONValue(val)); err == nil {
+			return string(data)
+		}
+	}
+	log.Printf("unknown column type %v", reflect.TypeOf(val))
+	return fmt.Sprintf("%v", val)
+}
+
+// toJSONValue converts the nested maps to ones with string keys which could be marshaled
+func toJSONValue(val interface{}) interface{} {
+	switch val.(type) {
+	case json.Marshaler, encoding.TextMarshaler:
+		return val
+	}
+	if text, ok := formatDuckDBValue(val); ok {
+		return text
+	}
+
+	value := reflect.ValueOf(val)
+	switch value.Kind() {
+	case reflect.Map:
+		items := make(map[string]interface{}, value.Len())
+		for iter := value.MapRange(); iter.Next(); {
+			items[fmt.Sprint(iter.Key().Interface())] = toJSONValue(iter.Value().Interface())
+		}
+		return items
+	case reflect.Slice:
+		if value.Type().Elem().Kind() == reflect.Uint8 {
+			return val
+		}
+		items := make([]interface{}, value.Len())
+		for i := range items {
+			items[i] = toJSONValue(value.Index(i).Interface())
+		}
+		return items
+	}
+	return val
+}
+
+func columnTypeName(columnTypes []*sql.ColumnType, index int) string {
+	if index < len(columnTypes) {
+		return strings.ToUpper(columnTypes[index].DatabaseTypeName())
+	}
+	return ""
+}
+
 const queryDatabaseSql = "show databases"
 
 type DataQuery interface {
 	GetDatabases(context.Context) (databases []string, err error)
 	GetTables(ctx context.Context, currentDatabase string) (tables []string, err error)
 	GetCurrentDatabase() (string, error)
-	GetLabels(context.Context, string) []*server.Pair
+	GetLabels(ctx context.Context, sql string, args ...interface{}) []*server.Pair
 	GetClient() *gorm.DB
 	GetInnerSQL() InnerSQL
 }
 
+// TableKindsQuery is implemented by the DataQuery which has different kinds of tables,
+// the kinds are returned as the labels of the query result
+type TableKindsQuery interface {
+	GetTableKinds(ctx context.Context, currentDatabase string) ([]*server.Pair, error)
+}
+
 type commonDataQuery struct {
 	db       *gorm.DB
 	innerSQL InnerSQL
@@ -240,6 +632,26 @@ func NewCommonDataQuery(innerSQL InnerSQL, db *gorm.DB) DataQuery {
 	}
 }
 
+// NewDataQuery creates the DataQuery of the driver
+func NewDataQuery(driver string, db *gorm.DB) DataQuery {
+	common := &commonDataQuery{
+		innerSQL: GetInnerSQL(driver),
+		db:       db,
+	}
+
+	switch driver {
+	case DialectorSQLServer:
+		return &sqlServerDataQuery{commonDataQuery: common}
+	case DialectorTDengine:
+		return &tdengineDataQuery{commonDataQuery: common}
+	case DialectorGreptime:
+		common.innerSQL = &greptimeDialect{postgres: db.Dialector != nil && db.Dialector.Name() == DialectorPostgres}
+		return &greptimeDataQuery{commonDataQuery: common}
+	default:
+		return common
+	}
+}
+
 func (q *commonDataQuery) GetDatabases(ctx context.Context) (databases []string, err error) {
 	var databaseResult *server.DataQueryResult
 	if databaseResult, err = sqlQuery(ctx, q.GetInnerSQL().ToNativeSQL(InnerShowDatabases), q.db); err == nil {
@@ -265,16 +677,23 @@ func (q *commonDataQuery) GetDatabases(ctx context.Context) (databases []string,
 
 func (q *commonDataQuery) GetTables(ctx context.Context, currentDatabase string) (tables []string, err error) {
 	showTables := q.GetInnerSQL().ToNativeSQL(InnerShowTables)
+	var args []interface{}
 	if strings.Contains(showTables, "%s") {
 		showTables = fmt.Sprintf(showTables, currentDatabase)
+	} else if strings.Contains(showTables, "?") {
+		// the database name is a bind parameter, which goes with the native placeholder
+		params := &bindParams{Positional: []interface{}{currentDatabase}}
+		if showTables, args, err = params.bind(showTables, q.db); err != nil {
+			return
+		}
 	}
 
 	var tableResult *server.DataQueryResult
-	if tableResult, err = sqlQuery(ctx, showTables, q.db); err == nil {
+	if tableResult, err = sqlQuery(ctx, showTables, q.db, args...); err == nil {
 		for _, table := range tableResult.Items {
 			for _, item := range table.GetData() {
 				if item.Key == fmt.Sprintf("Tables_in_%s", currentDatabase) || item.Key == "table_name" ||
-					item.Key == "Tables" || item.Key == "tablename" {
+					item.Key == "Tables" || item.Key == "tablename" || item.Key == "name" {
 					var found bool
 					for _, name := range tables {
 						if name == item.Value {
@@ -300,10 +719,10 @@ func (q *commonDataQuery) GetCurrentDatabase() (current string, err error) {
 	return
 }
 
-func (q *commonDataQuery) GetLabels(ctx context.Context, sql string) (metadata []*server.Pair) {
+func (q *commonDataQuery) GetLabels(ctx context.Context, sql string, args ...interface{}) (metadata []*server.Pair) {
 	metadata = make([]*server.Pair, 0)
 	if !strings.Contains(sql, ";") {
-		if databaseResult, err := sqlQuery(ctx, fmt.Sprintf("explain %s", sql), q.db); err == nil && len(databaseResult.Items) != 1 {
+		if databaseResult, err := sqlQuery(ctx, fmt.Sprintf("explain %s", sql), q.db, args...); err == nil && len(databaseResult.Items) != 1 {
 			for _, data := range databaseResult.Items[0].Data {
 				switch data.Key {
 				case "type":
@@ -17,8 +17,10 @@ package pkg
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
 
+	"github.com/linuxsuren/api-testing/pkg/server"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -37,9 +39,39 @@ func TestRunMultilineSQL(t *testing.T) {
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
-			result, err := runMultilineSQL(context.TODO(), test.sql, nil)
+			result, err := runMultilineSQL(context.TODO(), test.sql, nil, queryPaging{})
 			assert.NoError(t, err)
 			assert.Nil(t, result)
 		})
 	}
 }
+
+func TestRunMultilineSQLResultSets(t *testing.T) {
+	db, err := openMemoryDB()
+	assert.NoError(t, err)
+
+	result, err := runMultilineSQL(context.TODO(), "SELECT 1 AS a; -- comment; only\nSELECT 'x;y' AS b, 2 AS a;", db, queryPaging{})
+	assert.NoError(t, err)
+	assert.Equal(t, []*server.Pairs{
+		{Data: []*server.Pair{{Key: "a", Value: "1"}}},
+		{Data: []*server.Pair{{Key: "b", Value: "x;y"}, {Key: "a", Value: "2"}}},
+	}, result.Items)
+
+	assert.Equal(t, `["a","b"]`, labelOf(result.Meta.Labels, "_columns"))
+	var resultSets []resultSet
+	assert.NoError(t, json.Unmarshal([]byte(labelOf(result.Meta.Labels, "_result_sets")), &resultSets))
+	if assert.Len(t, resultSets, 2) {
+		assert.Equal(t, "SELECT 1 AS a", resultSets[0].SQL)
+		assert.Equal(t, []string{"a"}, resultSets[0].Columns)
+		assert.Equal(t, 0, resultSets[0].Offset)
+		assert.Equal(t, 1, resultSets[0].Rows)
+		assert.NotEmpty(t, resultSets[0].Duration)
+		assert.Equal(t, "-- comment; only\nSELECT 'x;y' AS b, 2 AS a", resultSets[1].SQL)
+		assert.Equal(t, []string{"b", "a"}, resultSets[1].Columns)
+		assert.Equal(t, 1, resultSets[1].Offset)
+		assert.Equal(t, 1, resultSets[1].Rows)
+	}
+
+	_, err = runMultilineSQL(context.TODO(), "SELECT 1; SELECT * FROM fake", db, queryPaging{})
+	assert.ErrorContains(t, err, "failed to run statement 2")
+}
@@ -0,0 +1,161 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	propDSN    = "dsn"
+	propParams = "params"
+)
+
+// buildDSN generates the DSN of the driver. The property dsn overrides the generated one,
+// and the property params (query-string style) is merged into the generated one.
+func buildDSN(user, password, address, database, driver string, tlsOpts tlsOptions,
+	properties map[string]string) (dsn string, err error) {
+	var params url.Values
+	if v, ok := getProperty(properties, propParams); ok && v != "" {
+		if params, err = url.ParseQuery(v); err != nil {
+			err = fmt.Errorf("failed to parse %s: %v", propParams, err)
+			return
+		}
+	}
+
+	switch driver {
+	case DialectorMySQL, "":
+		if dsn, err = mysqlDSN(user, password, address, database, 3306, tlsOpts, params); err != nil {
+			return
+		}
+	case DialectorGreptime:
+		if dsn, err = greptimeDSN(user, password, address, database, tlsOpts, properties, params); err != nil {
+			return
+		}
+	case "sqlite", driverSQLitePureGo:
+		var opts sqliteOptions
+		if opts, err = parseSQLiteOptions(address, database, properties); err != nil {
+			return
+		}
+		dsn = opts.dsn(params, isPureGoSQLite(driver))
+	case DialectorPostgres:
+		dsn = postgresKeywordDSN(user, password, address, database, 5432, tlsOpts, properties, params)
+	case DialectorSQLServer:
+		if dsn, err = sqlServerDSN(user, password, address, database, tlsOpts, params); err != nil {
+			return
+		}
+	case DialectorClickHouse:
+		dsn = clickHouseDSN(user, password, address, database, params)
+	case DialectorDuckDB:
+		if dsn, err = duckdbDSN(address, properties, params); err != nil {
+			return
+		}
+	case DialectorTDengine:
+		dsn = withQuery(fmt.Sprintf("%s:%s@ws(%s)/%s", user, password, address, database), params)
+	default:
+		err = fmt.Errorf("invalid database driver %q", driver)
+		return
+	}
+
+	if v, ok := getProperty(properties, propDSN); ok && v != "" {
+		dsn = v
+	}
+	return
+}
+
+func mysqlDSN(user, password, address, database string, defaultPort int, tlsOpts tlsOptions,
+	params url.Values) (dsn string, err error) {
+	if !strings.Contains(address, ":") {
+		address = fmt.Sprintf("%s:%d", address, defaultPort)
+	}
+	query := url.Values{
+		"charset":   {"utf8mb4"},
+		"parseTime": {"true"},
+	}
+
+	var tlsName string
+	if tlsName, err = tlsOpts.registerMySQLTLS(strings.Split(address, ":")[0]); err != nil {
+		return
+	} else if tlsName != "" {
+		query.Set("tls", tlsName)
+	}
+	dsn = fmt.Sprintf("%s:%s@tcp(%s)/%s?%s", user, password, address, database, mergeParams(query, params).Encode())
+	return
+}
+
+func postgresKeywordDSN(user, password, address, database string, defaultPort int, tlsOpts tlsOptions,
+	properties map[string]string, params url.Values) string {
+	obj := strings.Split(address, ":")
+	host, port := obj[0], strconv.Itoa(defaultPort)
+	if len(obj) > 1 {
+		port = obj[1]
+	}
+	query := url.Values{
+		"host":     {host},
+		"user":     {user},
+		"password": {password},
+		"dbname":   {database},
+		"port":     {port},
+		"sslmode":  {tlsOpts.Mode},
+	}
+	if v, ok := getProperty(properties, propTimezone); ok && v != "" {
+		query.Set("TimeZone", v)
+	}
+	return postgresDSN(mergeParams(query, params))
+}
+
+// mergeParams merges the params into base, the params take precedence
+func mergeParams(base, params url.Values) url.Values {
+	for key, val := range params {
+		base[key] = val
+	}
+	return base
+}
+
+func withQuery(dsn string, params url.Values) string {
+	if len(params) == 0 {
+		return dsn
+	}
+	return dsn + "?" + params.Encode()
+}
+
+// postgresDSN encodes the params as the keyword/value connection string
+func postgresDSN(params url.Values) string {
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, fmt.Sprintf("%s=%s", key, quotePostgresValue(params.Get(key))))
+	}
+	return strings.Join(pairs, " ")
+}
+
+func quotePostgresValue(val string) string {
+	if val != "" && !strings.ContainsAny(val, ` '\`) {
+		return val
+	}
+	val = strings.ReplaceAll(val, `\`, `\\`)
+	val = strings.ReplaceAll(val, `'`, `\'`)
+	return "'" + val + "'"
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"math/big"
+	"net/url"
+	"strings"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// duckdbDSN uses the store URL as the database file, empty means an in-memory database
+func duckdbDSN(address string, properties map[string]string, params url.Values) (dsn string, err error) {
+	var readOnly bool
+	if readOnly, err = parseBoolProperty(properties, propReadOnly); err != nil {
+		return
+	}
+
+	query := url.Values{}
+	if readOnly {
+		query.Set("access_mode", "READ_ONLY")
+	}
+	dsn = withQuery(address, mergeParams(query, params))
+	return
+}
+
+// duckdbDialector reuses the PostgreSQL dialector, the SQL of DuckDB is compatible with it
+type duckdbDialector struct {
+	postgres.Dialector
+}
+
+var _ gorm.Dialector = &duckdbDialector{}
+
+func (d duckdbDialector) Name() string {
+	return DialectorDuckDB
+}
+
+// formatScaledInt formats the unscaled value of a decimal, e.g. 1250 with scale 2 to 12.50
+func formatScaledInt(value *big.Int, scale int) string {
+	digits := new(big.Int).Abs(value).String()
+	if scale > 0 {
+		if len(digits) <= scale {
+			digits = strings.Repeat("0", scale-len(digits)+1) + digits
+		}
+		digits = digits[:len(digits)-scale] + "." + digits[len(digits)-scale:]
+	}
+	if value.Sign() < 0 {
+		digits = "-" + digits
+	}
+	return digits
+}
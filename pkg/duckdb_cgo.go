@@ -0,0 +1,51 @@
+//go:build cgo && !purego
+
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/marcboeker/go-duckdb"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newDuckDBDialector(dsn string) (dialector gorm.Dialector, err error) {
+	// all the connections must share one connector, or each of them opens a new database
+	var connector *duckdb.Connector
+	if connector, err = duckdb.NewConnector(dsn, nil); err == nil {
+		dialector = &duckdbDialector{
+			Dialector: postgres.Dialector{Config: &postgres.Config{
+				Conn: sql.OpenDB(connector),
+			}},
+		}
+	}
+	return
+}
+
+// formatDuckDBValue formats the DuckDB values which are not readable as JSON
+func formatDuckDBValue(val interface{}) (text string, ok bool) {
+	switch v := val.(type) {
+	case duckdb.Decimal:
+		text, ok = formatScaledInt(v.Value, int(v.Scale)), true
+	case duckdb.Interval:
+		text, ok = fmt.Sprintf("%d months %d days %d microseconds", v.Months, v.Days, v.Micros), true
+	}
+	return
+}
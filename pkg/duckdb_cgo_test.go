@@ -0,0 +1,106 @@
+//go:build cgo && !purego
+
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/linuxsuren/api-testing/pkg/server"
+	atest "github.com/linuxsuren/api-testing/pkg/testing"
+	"github.com/linuxsuren/api-testing/pkg/testing/remote"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestDuckDB(t *testing.T) {
+	fixture := filepath.Join(t.TempDir(), "users.csv")
+	assert.NoError(t, os.WriteFile(fixture, []byte("id,name\n1,alice\n2,bob\n"), 0o644))
+
+	remoteServer := NewRemoteServer(10, "UTC")
+	ctx := remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
+		Name: "duckdb",
+		Properties: map[string]string{
+			"driver": DialectorDuckDB,
+		},
+	})
+
+	t.Run("query over a CSV file", func(t *testing.T) {
+		result, err := remoteServer.Query(ctx, &server.DataQuery{
+			Sql: fmt.Sprintf("SELECT name FROM read_csv('%s') ORDER BY id", fixture),
+		})
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 2)
+		assert.Equal(t, "alice", result.Items[0].Data[0].Value)
+		assert.Equal(t, "memory", result.Meta.CurrentDatabase)
+		assert.Contains(t, result.Meta.Databases, "memory")
+	})
+
+	t.Run("nested types", func(t *testing.T) {
+		result, err := remoteServer.Query(ctx, &server.DataQuery{
+			Sql: "SELECT [1, 2] AS list, {'a': 1, 'b': 'x'} AS struct, MAP {'k': [1]} AS map, " +
+				"170141183460469231731687303715884105727::HUGEINT AS huge, 12.50::DECIMAL(5,2) AS price, " +
+				"'8c2b5b34-1b6e-4f4f-9d2a-3f5f3c0e9a11'::UUID AS id, INTERVAL 1 DAY AS span",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []*server.Pair{
+			{Key: "list", Value: "[1,2]"},
+			{Key: "struct", Value: `{"a":1,"b":"x"}`},
+			{Key: "map", Value: `{"k":[1]}`},
+			{Key: "huge", Value: "170141183460469231731687303715884105727"},
+			{Key: "price", Value: "12.50"},
+			{Key: "id", Value: "8c2b5b34-1b6e-4f4f-9d2a-3f5f3c0e9a11"},
+			{Key: "span", Value: "0 months 1 days 0 microseconds"},
+		}, result.Items[0].Data)
+	})
+
+	t.Run("schemas and tables", func(t *testing.T) {
+		_, err := remoteServer.Query(ctx, &server.DataQuery{
+			Sql: "CREATE TABLE users AS SELECT * FROM read_csv('" + fixture + "'); " +
+				"CREATE SCHEMA report; CREATE TABLE report.daily (day DATE)",
+		})
+		assert.NoError(t, err)
+
+		result, err := remoteServer.Query(ctx, &server.DataQuery{
+			Sql: InnerDescribeTable_ + "users",
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"report.daily", "users"}, result.Meta.Tables)
+		assert.Len(t, result.Items, 2)
+	})
+
+	t.Run("tables of the database with a quote", func(t *testing.T) {
+		dialector, err := newDuckDBDialector("")
+		assert.NoError(t, err)
+		db, err := gorm.Open(dialector, &gorm.Config{})
+		assert.NoError(t, err)
+		assert.NoError(t, db.Exec(`ATTACH ':memory:' AS "it's"; CREATE TABLE "it's".main.users (id INTEGER)`).Error)
+
+		tables, err := NewDataQuery(DialectorDuckDB, db).GetTables(ctx, "it's")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"users"}, tables)
+	})
+
+	t.Run("store tables are not supported", func(t *testing.T) {
+		_, err := remoteServer.CreateTestSuite(ctx, &remote.TestSuite{Name: "test"})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,53 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"math/big"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDuckDBDSN(t *testing.T) {
+	dsn, err := duckdbDSN("", nil, nil)
+	assert.NoError(t, err)
+	assert.Empty(t, dsn)
+
+	dsn, err = duckdbDSN("/data/fixtures.duckdb", map[string]string{propReadOnly: "true"}, url.Values{"threads": {"4"}})
+	assert.NoError(t, err)
+	assert.Equal(t, "/data/fixtures.duckdb?access_mode=READ_ONLY&threads=4", dsn)
+
+	_, err = duckdbDSN("", map[string]string{propReadOnly: "fake"}, nil)
+	assert.Error(t, err)
+}
+
+func TestFormatScaledInt(t *testing.T) {
+	tests := []struct {
+		value  int64
+		scale  int
+		expect string
+	}{
+		{value: 1250, scale: 2, expect: "12.50"},
+		{value: -5, scale: 3, expect: "-0.005"},
+		{value: 42, scale: 0, expect: "42"},
+		{value: 0, scale: 2, expect: "0.00"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.expect, formatScaledInt(big.NewInt(tt.value), tt.scale))
+	}
+}
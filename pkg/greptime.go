@@ -0,0 +1,135 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/linuxsuren/api-testing/pkg/server"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+const propProtocol = "protocol"
+
+const (
+	greptimeProtocolMySQL    = "mysql"
+	greptimeProtocolPostgres = "postgres"
+	greptimeMySQLPort        = 4002
+	greptimePostgresPort     = 4003
+)
+
+// parseGreptimeProtocol returns the wire protocol to connect GreptimeDB, the default is mysql
+func parseGreptimeProtocol(properties map[string]string) (protocol string, err error) {
+	protocol = greptimeProtocolMySQL
+	if v, ok := getProperty(properties, propProtocol); ok && v != "" {
+		protocol = strings.ToLower(v)
+	}
+
+	switch protocol {
+	case greptimeProtocolMySQL, greptimeProtocolPostgres:
+	default:
+		err = fmt.Errorf("invalid %s %q, should be %s or %s", propProtocol, protocol,
+			greptimeProtocolMySQL, greptimeProtocolPostgres)
+	}
+	return
+}
+
+func greptimeDSN(user, password, address, database string, tlsOpts tlsOptions,
+	properties map[string]string, params url.Values) (dsn string, err error) {
+	var protocol string
+	if protocol, err = parseGreptimeProtocol(properties); err != nil {
+		return
+	}
+
+	if protocol == greptimeProtocolPostgres {
+		dsn = postgresKeywordDSN(user, password, address, database, greptimePostgresPort, tlsOpts, properties, params)
+	} else {
+		dsn, err = mysqlDSN(user, password, address, database, greptimeMySQLPort, tlsOpts, params)
+	}
+	return
+}
+
+func newGreptimeDialector(dsn string, tlsOpts tlsOptions, properties map[string]string) (dialector gorm.Dialector, err error) {
+	var protocol string
+	if protocol, err = parseGreptimeProtocol(properties); err != nil {
+		return
+	}
+
+	if protocol == greptimeProtocolPostgres {
+		dialector, err = newPostgresDialector(dsn, tlsOpts)
+	} else {
+		dialector = mysql.Open(dsn)
+	}
+	return
+}
+
+// isTQL reports whether the query is a TQL statement, which runs PromQL in GreptimeDB
+func isTQL(query string) bool {
+	fields := strings.Fields(query)
+	return len(fields) > 0 && strings.EqualFold(fields[0], "TQL")
+}
+
+type greptimeDataQuery struct {
+	*commonDataQuery
+}
+
+// GetLabels returns the query language, the logical plan of a SQL query, and the server version
+func (q *greptimeDataQuery) GetLabels(ctx context.Context, sql string, args ...interface{}) (metadata []*server.Pair) {
+	language := "sql"
+	if isTQL(sql) {
+		language = "promql"
+	}
+	metadata = []*server.Pair{{
+		Key:   "query_language",
+		Value: language,
+	}}
+
+	if language == "sql" && !strings.Contains(sql, ";") {
+		var plans []struct {
+			PlanType string
+			Plan     string
+		}
+		tx := q.db.WithContext(ctx).Raw("EXPLAIN " + sql)
+		// the placeholders are native already, so the values are bound without rewriting the SQL
+		tx.Statement.Vars = args
+		if err := tx.Scan(&plans).Error; err != nil {
+			log.Printf("failed to explain the query: %v", err)
+		} else {
+			for _, plan := range plans {
+				if plan.PlanType == "logical_plan" {
+					metadata = append(metadata, &server.Pair{
+						Key:   "logical_plan",
+						Value: plan.Plan,
+					})
+				}
+			}
+		}
+	}
+
+	var version string
+	if err := q.db.WithContext(ctx).Raw("SELECT version()").Row().Scan(&version); err == nil {
+		metadata = append(metadata, &server.Pair{
+			Key:   "version",
+			Value: version,
+		})
+	}
+	return
+}
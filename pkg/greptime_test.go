@@ -0,0 +1,150 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/linuxsuren/api-testing/pkg/server"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newMockGreptime(t *testing.T, protocol string) (DataQuery, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	var dialector gorm.Dialector
+	if protocol == greptimeProtocolPostgres {
+		dialector = postgres.New(postgres.Config{Conn: sqlDB})
+	} else {
+		dialector = mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true})
+	}
+	db, err := gorm.Open(dialector, &gorm.Config{})
+	assert.NoError(t, err)
+	return NewDataQuery(DialectorGreptime, db), mock
+}
+
+func TestGreptimeDataQuery(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("GetTables", func(t *testing.T) {
+		for protocol, query := range map[string]string{
+			greptimeProtocolMySQL:    "SHOW TABLES FROM `public`",
+			greptimeProtocolPostgres: `SHOW TABLES FROM "public"`,
+		} {
+			dataQuery, mock := newMockGreptime(t, protocol)
+			mock.ExpectQuery(query).
+				WillReturnRows(sqlmock.NewRows([]string{"Tables"}).AddRow("numbers").AddRow("cpu"))
+
+			tables, err := dataQuery.GetTables(ctx, "public")
+			assert.NoError(t, err, protocol)
+			assert.Equal(t, []string{"cpu", "numbers"}, tables, protocol)
+			assert.NoError(t, mock.ExpectationsWereMet(), protocol)
+		}
+	})
+
+	t.Run("GetLabels", func(t *testing.T) {
+		query, mock := newMockGreptime(t, greptimeProtocolMySQL)
+		mock.ExpectQuery("EXPLAIN SELECT * FROM cpu").
+			WillReturnRows(sqlmock.NewRows([]string{"plan_type", "plan"}).
+				AddRow("logical_plan", "MergeScan [is_placeholder=false]").
+				AddRow("physical_plan", "MergeScanExec"))
+		mock.ExpectQuery("SELECT version()").
+			WillReturnRows(sqlmock.NewRows([]string{"version()"}).AddRow("8.4.2-greptimedb-0.12.0"))
+
+		assert.Equal(t, []*server.Pair{
+			{Key: "query_language", Value: "sql"},
+			{Key: "logical_plan", Value: "MergeScan [is_placeholder=false]"},
+			{Key: "version", Value: "8.4.2-greptimedb-0.12.0"},
+		}, query.GetLabels(ctx, "SELECT * FROM cpu"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetLabels with the native placeholders", func(t *testing.T) {
+		query, mock := newMockGreptime(t, greptimeProtocolPostgres)
+		mock.ExpectQuery("EXPLAIN SELECT * FROM cpu WHERE host = $1 AND note = '?' AND usage > $2").WithArgs("host1", 0.5).
+			WillReturnRows(sqlmock.NewRows([]string{"plan_type", "plan"}).AddRow("logical_plan", "Filter"))
+		mock.ExpectQuery("SELECT version()").WillReturnError(errors.New("unsupported"))
+
+		assert.Equal(t, []*server.Pair{
+			{Key: "query_language", Value: "sql"},
+			{Key: "logical_plan", Value: "Filter"},
+		}, query.GetLabels(ctx, "SELECT * FROM cpu WHERE host = $1 AND note = '?' AND usage > $2", "host1", 0.5))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetLabels of TQL", func(t *testing.T) {
+		query, mock := newMockGreptime(t, greptimeProtocolPostgres)
+		mock.ExpectQuery("SELECT version()").WillReturnError(errors.New("unsupported"))
+
+		assert.Equal(t, []*server.Pair{{Key: "query_language", Value: "promql"}},
+			query.GetLabels(ctx, "tql eval (0, 10, '5s') rate(cpu_usage[1m])"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestGreptimeDialect(t *testing.T) {
+	dialect := GetInnerSQL(DialectorGreptime)
+	tests := []struct {
+		query  string
+		expect string
+	}{{
+		query:  InnerSelectTableLimit_ + "cpu",
+		expect: "SELECT * FROM cpu LIMIT 100",
+	}, {
+		query:  InnerDescribeTable_ + "cpu",
+		expect: "DESC TABLE cpu",
+	}, {
+		query:  InnerShowDatabases,
+		expect: "SHOW DATABASES",
+	}, {
+		query:  InnerCurrentDB,
+		expect: "SELECT DATABASE() AS name",
+	}, {
+		query:  "TQL EVAL (0, 10, '5s') cpu_usage",
+		expect: "TQL EVAL (0, 10, '5s') cpu_usage",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.expect, dialect.ToNativeSQL(tt.query))
+		})
+	}
+	assert.True(t, isTQL("  tql eval (0, 10, '5s') cpu_usage"))
+	assert.False(t, isTQL("SELECT 'TQL'"))
+}
+
+func TestNewGreptimeDialector(t *testing.T) {
+	dialector, err := newGreptimeDialector("root:pass@tcp(localhost:4002)/public", tlsOptions{}, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, DialectorMySQL, dialector.Name())
+
+	dialector, err = newGreptimeDialector("host=localhost port=4003", tlsOptions{},
+		map[string]string{propProtocol: greptimeProtocolPostgres})
+	assert.NoError(t, err)
+	assert.Equal(t, DialectorPostgres, dialector.Name())
+
+	_, err = newGreptimeDialector("", tlsOptions{}, map[string]string{propProtocol: "grpc"})
+	assert.Error(t, err)
+}
@@ -35,6 +35,16 @@ func GetInnerSQL(dialect string) InnerSQL {
 	switch dialect {
 	case DialectorPostgres:
 		return &postgresDialect{}
+	case DialectorSQLServer:
+		return &sqlServerDialect{}
+	case DialectorClickHouse:
+		return &clickHouseDialect{}
+	case DialectorDuckDB:
+		return &duckdbDialect{}
+	case DialectorTDengine:
+		return &tdengineDialect{}
+	case DialectorGreptime:
+		return &greptimeDialect{}
 	default:
 		return &mysqlDialect{}
 	}
@@ -79,3 +89,138 @@ func (p *postgresDialect) ToNativeSQL(query string) (sql string) {
 	}
 	return
 }
+
+type clickHouseDialect struct{}
+
+func (c *clickHouseDialect) ToNativeSQL(query string) (sql string) {
+	if strings.HasPrefix(query, InnerSelectTable_) {
+		sql = "SELECT * FROM " + strings.ReplaceAll(query, InnerSelectTable_, "")
+	} else if strings.HasPrefix(query, InnerSelectTableLimit_) {
+		sql = "SELECT * FROM " + strings.ReplaceAll(query, InnerSelectTableLimit_, "") + " LIMIT 100"
+	} else if strings.HasPrefix(query, InnerDescribeTable_) {
+		sql = "DESCRIBE TABLE " + strings.ReplaceAll(query, InnerDescribeTable_, "")
+	} else if query == InnerShowDatabases {
+		sql = "SHOW DATABASES"
+	} else if query == InnerShowTables {
+		sql = "SHOW TABLES FROM `%s`"
+	} else if query == InnerCurrentDB {
+		sql = "SELECT currentDatabase() AS name"
+	} else {
+		sql = query
+	}
+	return
+}
+
+type duckdbDialect struct{}
+
+func (d *duckdbDialect) ToNativeSQL(query string) (sql string) {
+	if strings.HasPrefix(query, InnerSelectTable_) {
+		sql = "SELECT * FROM " + strings.ReplaceAll(query, InnerSelectTable_, "")
+	} else if strings.HasPrefix(query, InnerSelectTableLimit_) {
+		sql = "SELECT * FROM " + strings.ReplaceAll(query, InnerSelectTableLimit_, "") + " LIMIT 100"
+	} else if strings.HasPrefix(query, InnerDescribeTable_) {
+		sql = "DESCRIBE " + strings.ReplaceAll(query, InnerDescribeTable_, "")
+	} else if query == InnerShowDatabases {
+		sql = "SELECT database_name AS name FROM duckdb_databases() WHERE NOT internal"
+	} else if query == InnerShowTables {
+		// the tables out of the default schema are qualified by the schema name
+		sql = "SELECT CASE WHEN table_schema = 'main' THEN table_name ELSE table_schema || '.' || table_name END " +
+			"AS table_name FROM information_schema.tables WHERE table_catalog = ?"
+	} else if query == InnerCurrentDB {
+		sql = "SELECT current_database() AS name"
+	} else {
+		sql = query
+	}
+	return
+}
+
+type tdengineDialect struct{}
+
+func (t *tdengineDialect) ToNativeSQL(query string) (sql string) {
+	if strings.HasPrefix(query, InnerSelectTable_) {
+		sql = "SELECT * FROM " + strings.ReplaceAll(query, InnerSelectTable_, "")
+	} else if strings.HasPrefix(query, InnerSelectTableLimit_) {
+		sql = "SELECT * FROM " + strings.ReplaceAll(query, InnerSelectTableLimit_, "") + " LIMIT 100"
+	} else if strings.HasPrefix(query, InnerDescribeTable_) {
+		sql = "DESCRIBE " + strings.ReplaceAll(query, InnerDescribeTable_, "")
+	} else if query == InnerShowDatabases {
+		sql = "SHOW DATABASES"
+	} else if query == InnerShowTables {
+		sql = "SHOW `%s`.TABLES"
+	} else if query == InnerCurrentDB {
+		sql = "SELECT DATABASE() AS name"
+	} else {
+		sql = query
+	}
+	return
+}
+
+// greptimeDialect works with both the MySQL and PostgreSQL protocols of GreptimeDB,
+// the TQL queries are passed through as they are
+type greptimeDialect struct {
+	postgres bool
+}
+
+func (g *greptimeDialect) ToNativeSQL(query string) (sql string) {
+	if strings.HasPrefix(query, InnerSelectTable_) {
+		sql = "SELECT * FROM " + strings.ReplaceAll(query, InnerSelectTable_, "")
+	} else if strings.HasPrefix(query, InnerSelectTableLimit_) {
+		sql = "SELECT * FROM " + strings.ReplaceAll(query, InnerSelectTableLimit_, "") + " LIMIT 100"
+	} else if strings.HasPrefix(query, InnerDescribeTable_) {
+		// the column Semantic Type tells the time index, tags and fields
+		sql = "DESC TABLE " + strings.ReplaceAll(query, InnerDescribeTable_, "")
+	} else if query == InnerShowDatabases {
+		sql = "SHOW DATABASES"
+	} else if query == InnerShowTables {
+		if g.postgres {
+			sql = `SHOW TABLES FROM "%s"`
+		} else {
+			sql = "SHOW TABLES FROM `%s`"
+		}
+	} else if query == InnerCurrentDB {
+		sql = "SELECT DATABASE() AS name"
+	} else {
+		sql = query
+	}
+	return
+}
+
+type sqlServerDialect struct{}
+
+func (s *sqlServerDialect) ToNativeSQL(query string) (sql string) {
+	if strings.HasPrefix(query, InnerSelectTable_) {
+		sql = "SELECT * FROM " + quoteSQLServerName(strings.ReplaceAll(query, InnerSelectTable_, ""))
+	} else if strings.HasPrefix(query, InnerSelectTableLimit_) {
+		sql = "SELECT TOP 100 * FROM " + quoteSQLServerName(strings.ReplaceAll(query, InnerSelectTableLimit_, ""))
+	} else if strings.HasPrefix(query, InnerDescribeTable_) {
+		condition := "TABLE_NAME = " + quoteSQLServerString(strings.ReplaceAll(query, InnerDescribeTable_, ""))
+		if schema, table, ok := strings.Cut(strings.ReplaceAll(query, InnerDescribeTable_, ""), "."); ok {
+			condition = "TABLE_SCHEMA = " + quoteSQLServerString(schema) + " AND TABLE_NAME = " + quoteSQLServerString(table)
+		}
+		sql = "SELECT COLUMN_NAME AS Field, DATA_TYPE AS Type, CHARACTER_MAXIMUM_LENGTH AS Length, " +
+			"IS_NULLABLE AS [Null], COLUMN_DEFAULT AS [Default] FROM INFORMATION_SCHEMA.COLUMNS WHERE " +
+			condition + " ORDER BY ORDINAL_POSITION"
+	} else if query == InnerShowDatabases {
+		sql = "SELECT name FROM sys.databases"
+	} else if query == InnerShowTables {
+		sql = "SELECT TABLE_NAME AS table_name FROM INFORMATION_SCHEMA.TABLES WHERE TABLE_CATALOG = ? AND TABLE_TYPE = 'BASE TABLE'"
+	} else if query == InnerCurrentDB {
+		sql = "SELECT DB_NAME() AS name"
+	} else {
+		sql = query
+	}
+	return
+}
+
+// quoteSQLServerName quotes each part of the name, e.g. dbo.users to [dbo].[users]
+func quoteSQLServerName(name string) string {
+	parts := strings.Split(name, ".")
+	for i, part := range parts {
+		parts[i] = "[" + strings.ReplaceAll(part, "]", "]]") + "]"
+	}
+	return strings.Join(parts, ".")
+}
+
+func quoteSQLServerString(val string) string {
+	return "'" + strings.ReplaceAll(val, "'", "''") + "'"
+}
@@ -32,6 +32,7 @@ func TestInnerSQL(t *testing.T) {
 		const sql = "select * from table limit 3"
 		assert.Equal(t, sql, pkg.GetInnerSQL(pkg.DialectorMySQL).ToNativeSQL(sql))
 		assert.Equal(t, sql, pkg.GetInnerSQL(pkg.DialectorPostgres).ToNativeSQL(sql))
+		assert.Equal(t, sql, pkg.GetInnerSQL(pkg.DialectorSQLServer).ToNativeSQL(sql))
 	})
 
 	innerSQLs := []string{
@@ -45,6 +46,7 @@ func TestInnerSQL(t *testing.T) {
 		for _, sql := range innerSQLs {
 			assert.NotEqual(t, sql, pkg.GetInnerSQL(pkg.DialectorMySQL).ToNativeSQL(sql))
 			assert.NotEqual(t, sql, pkg.GetInnerSQL(pkg.DialectorPostgres).ToNativeSQL(sql))
+			assert.NotEqual(t, sql, pkg.GetInnerSQL(pkg.DialectorSQLServer).ToNativeSQL(sql))
 		}
 	})
 }
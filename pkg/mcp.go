@@ -17,6 +17,9 @@ package pkg
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
 
 	"github.com/linuxsuren/api-testing/pkg/server"
 	"github.com/linuxsuren/api-testing/pkg/testing"
@@ -29,7 +32,8 @@ type mcpServer struct {
 }
 
 type DBQuery struct {
-	SQL string `json:"sql" jsonschema:"the sql to be executed"`
+	SQL    string `json:"sql" jsonschema:"the sql to be executed"`
+	Params any    `json:"params,omitempty" jsonschema:"the values of the placeholders, an array for ? or an object for :name and @name"`
 }
 
 type DatabaseQuery interface {
@@ -47,9 +51,19 @@ func (s *mcpServer) Query(ctx context.Context, request *mcp.CallToolRequest, que
 	ctx = remote.WithIncomingStoreContext(ctx, s.store)
 	result = &mcp.CallToolResult{}
 
+	sqlText := query.SQL
+	if query.Params != nil {
+		var data []byte
+		if data, err = json.Marshal(query.Params); err != nil {
+			return
+		}
+		// the escaped slash keeps the end of the comment out of the values
+		sqlText = fmt.Sprintf("/* params: %s */ %s", strings.ReplaceAll(string(data), "*/", `*\/`), sqlText)
+	}
+
 	var queryResult *server.DataQueryResult
 	if queryResult, err = db.Query(ctx, &server.DataQuery{
-		Sql: query.SQL,
+		Sql: sqlText,
 	}); err == nil {
 		result.StructuredContent = queryResult
 		result.Content = []mcp.Content{
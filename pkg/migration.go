@@ -0,0 +1,562 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/linuxsuren/api-testing/pkg/testing"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const propAutoMigrate = "autoMigrate"
+
+// ErrSchemaTooNew indicates the store was migrated by a newer version of this extension
+var ErrSchemaTooNew = errors.New("the schema version of the store is newer than the supported one")
+
+// SchemaVersion records an applied migration
+type SchemaVersion struct {
+	Version   int `gorm:"primaryKey;autoIncrement:false"`
+	Name      string
+	AppliedAt time.Time
+}
+
+func (SchemaVersion) TableName() string {
+	return "schema_version"
+}
+
+// Migration is a versioned schema change
+type Migration struct {
+	Version int
+	Name    string
+	Up      func(tx *gorm.DB) error
+	Down    func(tx *gorm.DB) error
+}
+
+// MigrationState represents a migration and whether it was applied
+type MigrationState struct {
+	Version int
+	Name    string
+	Applied bool
+}
+
+const historySuiteCaseIndex = "idx_history_suite_case"
+
+// migrationsOf returns the ordered migrations of the driver
+func migrationsOf(driver string) []Migration {
+	switch driver {
+	case DialectorGreptime, DialectorClickHouse, DialectorDuckDB:
+		// the store tables are not supported yet
+		return nil
+	}
+
+	return []Migration{{
+		Version: 1,
+		Name:    "create the store tables",
+		Up: func(tx *gorm.DB) error {
+			return tx.AutoMigrate(&testCaseV1{}, &testSuiteV1{}, &historyTestResultV1{})
+		},
+		Down: func(tx *gorm.DB) error {
+			return tx.Migrator().DropTable(&historyTestResultV1{}, &testSuiteV1{}, &testCaseV1{})
+		},
+	}, {
+		Version: 2,
+		Name:    "index the suite and case names of history",
+		Up: func(tx *gorm.DB) error {
+			if tx.Migrator().HasIndex(&historyTestResultV1{}, historySuiteCaseIndex) {
+				return nil
+			}
+
+			columns := "suite_name, case_name"
+			switch tx.Dialector.Name() {
+			case DialectorMySQL:
+				// the TEXT columns need a prefix length in MySQL
+				columns = "suite_name(191), case_name(191)"
+			case DialectorSQLServer:
+				// the nvarchar(MAX) columns cannot be indexed in SQL Server
+				return nil
+			case DialectorTDengine:
+				// TDengine only indexes the tags of the super tables
+				return nil
+			}
+			return tx.Exec(fmt.Sprintf("CREATE INDEX %s ON history_test_results (%s)", historySuiteCaseIndex, columns)).Error
+		},
+		Down: func(tx *gorm.DB) error {
+			if !tx.Migrator().HasIndex(&historyTestResultV1{}, historySuiteCaseIndex) {
+				return nil
+			}
+			return tx.Migrator().DropIndex(&historyTestResultV1{}, historySuiteCaseIndex)
+		},
+	}, {
+		Version: 3,
+		Name:    "store the create time of history in UTC",
+		Up:      convertHistoryTimeToUTC,
+		Down:    convertHistoryTimeToLocal,
+	}}
+}
+
+// SchemaMigrator applies or reverts the migrations of a store
+type SchemaMigrator struct {
+	db         *gorm.DB
+	driver     string
+	migrations []Migration
+}
+
+func newSchemaMigrator(db *gorm.DB, driver string) *SchemaMigrator {
+	return &SchemaMigrator{
+		db:         primaryDB(db),
+		driver:     driver,
+		migrations: migrationsOf(driver),
+	}
+}
+
+// inTimezone sets the location of the legacy history time, which was stored as the local time
+func (m *SchemaMigrator) inTimezone(loc *time.Location) *SchemaMigrator {
+	m.db = m.db.WithContext(withTimezone(context.Background(), loc))
+	return m
+}
+
+// OpenSchemaMigrator connects to the store without migrating it
+func OpenSchemaMigrator(store *testing.Store) (migrator *SchemaMigrator, err error) {
+	properties := map[string]string{}
+	for k, v := range store.Properties {
+		properties[k] = v
+	}
+	properties[propAutoMigrate] = "false"
+	// the migrations only go to the primary
+	for k := range properties {
+		if strings.EqualFold(k, propReplicas) {
+			delete(properties, k)
+		}
+	}
+
+	database, _ := getProperty(properties, "database")
+	driver, _ := getProperty(properties, "driver")
+	if driver == "" {
+		driver = DialectorMySQL
+	}
+
+	var loc *time.Location
+	if loc, err = loadTimezone(properties, ""); err != nil {
+		return
+	}
+
+	var db *gorm.DB
+	if db, err = createDB(store.Username, store.Password, store.URL, database, driver, properties); err == nil {
+		migrator = newSchemaMigrator(db, driver).inTimezone(loc)
+	}
+	return
+}
+
+// Latest returns the latest version of the known migrations
+func (m *SchemaMigrator) Latest() (version int) {
+	if count := len(m.migrations); count > 0 {
+		version = m.migrations[count-1].Version
+	}
+	return
+}
+
+// Current returns the version of the store, 0 means no migration was applied
+func (m *SchemaMigrator) Current() (version int, err error) {
+	if len(m.migrations) == 0 || !m.db.Migrator().HasTable(&SchemaVersion{}) {
+		return
+	}
+
+	var latest SchemaVersion
+	result := m.db.Order("version desc").Limit(1).Find(&latest)
+	version, err = latest.Version, result.Error
+	return
+}
+
+// Status returns the state of all the known migrations
+func (m *SchemaMigrator) Status() (states []MigrationState, err error) {
+	var current int
+	if current, err = m.Current(); err != nil {
+		return
+	}
+	for _, migration := range m.migrations {
+		states = append(states, MigrationState{
+			Version: migration.Version,
+			Name:    migration.Name,
+			Applied: migration.Version <= current,
+		})
+	}
+	return
+}
+
+// Check refuses the store which has a newer schema
+func (m *SchemaMigrator) Check() (err error) {
+	var current int
+	if current, err = m.Current(); err == nil && current > m.Latest() {
+		err = fmt.Errorf("%w: %d > %d", ErrSchemaTooNew, current, m.Latest())
+	}
+	return
+}
+
+// Up applies the pending migrations until the target version, 0 means the latest one.
+// It only returns the pending migrations in the dry-run mode.
+func (m *SchemaMigrator) Up(target int, dryRun bool) (applied []Migration, err error) {
+	if err = m.Check(); err != nil {
+		return
+	}
+	if len(m.migrations) == 0 {
+		log.Printf("no migrations for the driver %q", m.driver)
+		return
+	}
+	if target <= 0 {
+		target = m.Latest()
+	}
+
+	var current int
+	if current, err = m.Current(); err != nil {
+		return
+	}
+	if !dryRun {
+		if err = m.db.AutoMigrate(&SchemaVersion{}); err != nil {
+			return
+		}
+	}
+
+	for _, migration := range m.migrations {
+		if migration.Version <= current || migration.Version > target {
+			continue
+		}
+
+		if !dryRun {
+			log.Printf("apply migration %d: %s", migration.Version, migration.Name)
+			if err = m.db.Transaction(func(tx *gorm.DB) (err error) {
+				if err = migration.Up(tx); err == nil {
+					err = tx.Create(&SchemaVersion{
+						Version:   migration.Version,
+						Name:      migration.Name,
+						AppliedAt: time.Now().UTC(),
+					}).Error
+				}
+				return
+			}); err != nil {
+				err = fmt.Errorf("failed to apply migration %d: %w", migration.Version, err)
+				return
+			}
+		}
+		applied = append(applied, migration)
+	}
+	return
+}
+
+// Down reverts the applied migrations which are newer than the target version.
+// It only returns the migrations to be reverted in the dry-run mode.
+func (m *SchemaMigrator) Down(target int, dryRun bool) (reverted []Migration, err error) {
+	if err = m.Check(); err != nil {
+		return
+	}
+
+	var current int
+	if current, err = m.Current(); err != nil {
+		return
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if migration.Version > current || migration.Version <= target {
+			continue
+		}
+
+		if !dryRun {
+			log.Printf("revert migration %d: %s", migration.Version, migration.Name)
+			if err = m.db.Transaction(func(tx *gorm.DB) (err error) {
+				if err = migration.Down(tx); err == nil {
+					err = tx.Delete(&SchemaVersion{}, "version = ?", migration.Version).Error
+				}
+				return
+			}); err != nil {
+				err = fmt.Errorf("failed to revert migration %d: %w", migration.Version, err)
+				return
+			}
+		}
+		reverted = append(reverted, migration)
+	}
+	return
+}
+
+// Close closes the connection of the store
+func (m *SchemaMigrator) Close() (err error) {
+	sqlDB, err := m.db.DB()
+	if err == nil {
+		err = sqlDB.Close()
+	}
+	return
+}
+
+func isAutoMigrate(properties map[string]string) bool {
+	if v, ok := getProperty(properties, propAutoMigrate); ok && v != "" {
+		if autoMigrate, err := strconv.ParseBool(v); err == nil {
+			return autoMigrate
+		}
+		log.Printf("failed to parse %s: %q", propAutoMigrate, v)
+	}
+	return true
+}
+
+// the tables of the migration 1, which are frozen copies of the models. A change of the models
+// needs a new migration instead of changing them.
+
+type testCaseV1 struct {
+	SuiteName string `gorm:"type:varchar(200);uniqueIndex:idx_name_and_suite_name"`
+	Name      string `gorm:"type:varchar(200);uniqueIndex:idx_name_and_suite_name"`
+	API       string
+	Method    string
+	Body      string
+	Header    string
+	Cookie    string
+	Query     string
+	Form      string
+
+	ExpectStatusCode int
+	ExpectBody       string
+	ExpectSchema     string
+	ExpectHeader     string
+	ExpectBodyFields string
+	ExpectVerify     string
+}
+
+func (testCaseV1) TableName() string {
+	return "test_cases"
+}
+
+type testSuiteV1 struct {
+	Name     string `gorm:"primaryKey"`
+	API      string
+	SpecKind string
+	SpecURL  string
+	Param    string
+}
+
+func (testSuiteV1) TableName() string {
+	return "test_suites"
+}
+
+type historyTestResultV1 struct {
+	ID               string `gorm:"primaryKey"`
+	HistorySuiteName string
+	CreateTime       string
+
+	SuiteName string
+	SuiteAPI  string
+	SpecKind  string
+	SpecURL   string
+	Param     string
+
+	CaseName      string
+	CaseAPI       string
+	Method        string
+	Body          string
+	Header        string
+	HistoryHeader string
+	Cookie        string
+	Query         string
+	Form          string
+
+	ExpectStatusCode int
+	ExpectBody       string
+	ExpectSchema     string
+	ExpectHeader     string
+	ExpectBodyFields string
+	ExpectVerify     string
+
+	Message    string
+	Error      string
+	StatusCode int32
+	Output     string
+}
+
+func (historyTestResultV1) TableName() string {
+	return "history_test_results"
+}
+
+// historyTimeV3 has the create time columns of the migration 3, the legacy create time
+// was a string of the local time in the layout historyTimeLayout
+type historyTimeV3 struct {
+	ID         string    `gorm:"primaryKey"`
+	CreateTime time.Time `gorm:"index"`
+	UTCTime    time.Time `gorm:"column:create_time_utc"`
+	LocalTime  string    `gorm:"column:create_time_local"`
+}
+
+func (historyTimeV3) TableName() string {
+	return "history_test_results"
+}
+
+// isStringColumn returns true if the column stores the strings
+func isStringColumn(tx *gorm.DB, model interface{}, name string) (ok bool, err error) {
+	var columns []gorm.ColumnType
+	if columns, err = tx.Migrator().ColumnTypes(model); err != nil {
+		return
+	}
+	for _, column := range columns {
+		if strings.EqualFold(column.Name(), name) {
+			columnType := strings.ToUpper(column.DatabaseTypeName())
+			ok = strings.Contains(columnType, "CHAR") || strings.Contains(columnType, "TEXT")
+			return
+		}
+	}
+	err = fmt.Errorf("column %q not found", name)
+	return
+}
+
+// dropColumn drops the column in place, the migrator of SQLite recreates the table without the other indexes
+func dropColumn(tx *gorm.DB, table, name string) error {
+	return tx.Exec("ALTER TABLE ? DROP COLUMN ?", clause.Table{Name: table}, clause.Column{Name: name}).Error
+}
+
+// replaceColumnInTDengine replaces the column of an empty table,
+// TDengine is not able to update the rows by key or change the column type
+func replaceColumnInTDengine(tx *gorm.DB, model interface{}, name, field string) (err error) {
+	var count int64
+	if err = tx.Model(model).Count(&count).Error; err != nil {
+		return
+	}
+	if count > 0 {
+		err = fmt.Errorf("the %s of %d history records cannot be converted in %s", name, count, DialectorTDengine)
+		return
+	}
+	if err = dropColumn(tx, historyTimeV3{}.TableName(), name); err == nil {
+		err = tx.Migrator().AddColumn(model, field)
+	}
+	return
+}
+
+// convertHistoryTimeToUTC converts the legacy create time strings to the UTC timestamps
+// The DDL is committed implicitly in MySQL, so it resumes the conversion which failed partially.
+func convertHistoryTimeToUTC(tx *gorm.DB) (err error) {
+	model := &historyTimeV3{}
+	migrator := tx.Migrator()
+	if migrator.HasColumn(model, "create_time_utc") && !migrator.HasColumn(model, "create_time") {
+		// the legacy column was dropped already
+		return renameUTCTime(migrator, model)
+	}
+	var legacy bool
+	if legacy, err = isStringColumn(tx, model, "create_time"); err != nil {
+		return
+	}
+	if !legacy {
+		if tx.Dialector.Name() == DialectorTDengine || migrator.HasIndex(model, "CreateTime") {
+			return
+		}
+		// the column was renamed without the index
+		return migrator.CreateIndex(model, "CreateTime")
+	}
+	if tx.Dialector.Name() == DialectorTDengine {
+		return replaceColumnInTDengine(tx, model, "create_time", "CreateTime")
+	}
+
+	var records []struct {
+		ID         string
+		CreateTime string
+	}
+	if err = tx.Model(model).Select("id", "create_time").Find(&records).Error; err != nil {
+		return
+	}
+	if !migrator.HasColumn(model, "UTCTime") {
+		if err = migrator.AddColumn(model, "UTCTime"); err != nil {
+			return
+		}
+	}
+
+	loc := timezoneFromContext(tx.Statement.Context)
+	for _, record := range records {
+		var createTime time.Time
+		if record.CreateTime != "" {
+			if createTime, err = time.ParseInLocation(historyTimeLayout, record.CreateTime, loc); err != nil {
+				err = fmt.Errorf("failed to convert the create time of history %q: %w", record.ID, err)
+				return
+			}
+		}
+		if err = tx.Model(model).Where("id = ?", record.ID).Update("create_time_utc", createTime.UTC()).Error; err != nil {
+			return
+		}
+	}
+
+	if err = dropColumn(tx, model.TableName(), "create_time"); err != nil {
+		return
+	}
+	return renameUTCTime(migrator, model)
+}
+
+func renameUTCTime(migrator gorm.Migrator, model *historyTimeV3) (err error) {
+	if err = migrator.RenameColumn(model, "create_time_utc", "create_time"); err == nil {
+		err = migrator.CreateIndex(model, "CreateTime")
+	}
+	return
+}
+
+// convertHistoryTimeToLocal converts the create time back to the legacy strings of the local time,
+// and resumes the conversion which failed partially like convertHistoryTimeToUTC.
+func convertHistoryTimeToLocal(tx *gorm.DB) (err error) {
+	model := &historyTimeV3{}
+	migrator := tx.Migrator()
+	if migrator.HasColumn(model, "create_time_local") && !migrator.HasColumn(model, "create_time") {
+		// the UTC column was dropped already
+		return migrator.RenameColumn(model, "create_time_local", "create_time")
+	}
+	var legacy bool
+	if legacy, err = isStringColumn(tx, model, "create_time"); err != nil || legacy {
+		return
+	}
+	if tx.Dialector.Name() == DialectorTDengine {
+		return replaceColumnInTDengine(tx, model, "create_time", "LocalTime")
+	}
+
+	var records []struct {
+		ID         string
+		CreateTime time.Time
+	}
+	if err = tx.Model(model).Select("id", "create_time").Find(&records).Error; err != nil {
+		return
+	}
+	if migrator.HasIndex(model, "CreateTime") {
+		if err = migrator.DropIndex(model, "CreateTime"); err != nil {
+			return
+		}
+	}
+	if !migrator.HasColumn(model, "LocalTime") {
+		if err = migrator.AddColumn(model, "LocalTime"); err != nil {
+			return
+		}
+	}
+
+	loc := timezoneFromContext(tx.Statement.Context)
+	for _, record := range records {
+		var createTime string
+		if !record.CreateTime.IsZero() {
+			createTime = record.CreateTime.In(loc).Format(historyTimeLayout)
+		}
+		if err = tx.Model(model).Where("id = ?", record.ID).Update("create_time_local", createTime).Error; err != nil {
+			return
+		}
+	}
+
+	if err = dropColumn(tx, model.TableName(), "create_time"); err != nil {
+		return
+	}
+	return migrator.RenameColumn(model, "create_time_local", "create_time")
+}
@@ -0,0 +1,334 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	atest "github.com/linuxsuren/api-testing/pkg/testing"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestSchemaMigrator(t *testing.T) {
+	store := &atest.Store{
+		Properties: map[string]string{
+			"driver":   "sqlite",
+			"database": filepath.Join(t.TempDir(), "migration"),
+		},
+	}
+	migrator, err := OpenSchemaMigrator(store)
+	assert.NoError(t, err)
+	defer migrator.Close()
+	db := migrator.db
+
+	t.Run("nothing applied", func(t *testing.T) {
+		current, err := migrator.Current()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, current)
+		assert.False(t, db.Migrator().HasTable(&TestCase{}))
+	})
+
+	t.Run("dry-run", func(t *testing.T) {
+		migrations, err := migrator.Up(0, true)
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 3)
+		assert.False(t, db.Migrator().HasTable(&SchemaVersion{}))
+	})
+
+	t.Run("up to a version", func(t *testing.T) {
+		migrations, err := migrator.Up(1, false)
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 1)
+		assert.True(t, db.Migrator().HasTable(&TestCase{}))
+		assert.False(t, db.Migrator().HasIndex(&HistoryTestResult{}, historySuiteCaseIndex))
+		// the frozen tables of the migration 1
+		for _, model := range []interface{}{&testCaseV1{}, &testSuiteV1{}, &historyTestResultV1{}} {
+			columns, err := db.Migrator().ColumnTypes(model)
+			assert.NoError(t, err)
+			stmt := &gorm.Statement{DB: db}
+			assert.NoError(t, stmt.Parse(model))
+			assert.Len(t, columns, len(stmt.Schema.DBNames), stmt.Table)
+		}
+
+		states, err := migrator.Status()
+		assert.NoError(t, err)
+		assert.Equal(t, []MigrationState{
+			{Version: 1, Name: "create the store tables", Applied: true},
+			{Version: 2, Name: "index the suite and case names of history"},
+			{Version: 3, Name: "store the create time of history in UTC"},
+		}, states)
+	})
+
+	t.Run("up to the latest", func(t *testing.T) {
+		migrations, err := migrator.Up(0, false)
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 2)
+		assert.True(t, db.Migrator().HasIndex(&HistoryTestResult{}, historySuiteCaseIndex))
+		assert.True(t, db.Migrator().HasIndex(&HistoryTestResult{}, "CreateTime"))
+
+		migrations, err = migrator.Up(0, false)
+		assert.NoError(t, err)
+		assert.Empty(t, migrations)
+	})
+
+	t.Run("down", func(t *testing.T) {
+		migrations, err := migrator.Down(1, false)
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 2)
+		assert.False(t, db.Migrator().HasIndex(&HistoryTestResult{}, historySuiteCaseIndex))
+
+		migrations, err = migrator.Down(0, false)
+		assert.NoError(t, err)
+		assert.Len(t, migrations, 1)
+		assert.False(t, db.Migrator().HasTable(&TestCase{}))
+
+		current, err := migrator.Current()
+		assert.NoError(t, err)
+		assert.Equal(t, 0, current)
+	})
+
+	t.Run("refuse a newer schema", func(t *testing.T) {
+		_, err := migrator.Up(0, false)
+		assert.NoError(t, err)
+		assert.NoError(t, db.Create(&SchemaVersion{Version: migrator.Latest() + 1, Name: "future"}).Error)
+
+		err = migrator.Check()
+		assert.True(t, errors.Is(err, ErrSchemaTooNew))
+		_, err = migrator.Up(0, false)
+		assert.Error(t, err)
+		_, err = createDB("", "", "", store.Properties["database"], "sqlite", nil)
+		assert.Error(t, err)
+	})
+
+	t.Run("failed migration is rolled back", func(t *testing.T) {
+		failed := newSchemaMigrator(db, "sqlite")
+		failed.migrations = append(migrationsOf("sqlite"), Migration{
+			Version: 10,
+			Name:    "broken",
+			Up: func(tx *gorm.DB) error {
+				return errors.New("fake")
+			},
+		})
+		assert.NoError(t, db.Delete(&SchemaVersion{}, "version > ?", 3).Error)
+
+		_, err := failed.Up(0, false)
+		assert.Error(t, err)
+		current, err := failed.Current()
+		assert.NoError(t, err)
+		assert.Equal(t, 3, current)
+	})
+}
+
+func TestConvertHistoryTime(t *testing.T) {
+	migrator, err := OpenSchemaMigrator(&atest.Store{
+		Properties: map[string]string{
+			"driver":   "sqlite",
+			"database": filepath.Join(t.TempDir(), "history"),
+			"timezone": "Asia/Shanghai",
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer migrator.Close()
+	db := migrator.db
+
+	// the rows of the legacy versions, which store the local time strings
+	_, err = migrator.Up(2, false)
+	assert.NoError(t, err)
+	assert.NoError(t, db.Create(&historyTestResultV1{ID: "old", CreateTime: "2025-01-02T08:04:05.123456789", SuiteName: "suite"}).Error)
+	assert.NoError(t, db.Create(&historyTestResultV1{ID: "empty"}).Error)
+
+	_, err = migrator.Up(0, false)
+	assert.NoError(t, err)
+	var records []HistoryTestResult
+	assert.NoError(t, db.Order("id").Find(&records).Error)
+	if assert.Len(t, records, 2) {
+		assert.True(t, records[0].CreateTime.IsZero())
+		assert.Equal(t, time.Date(2025, 1, 2, 0, 4, 5, 123456789, time.UTC), records[1].CreateTime.UTC())
+		assert.Equal(t, "suite", records[1].SuiteName)
+	}
+	assert.True(t, db.Migrator().HasIndex(&HistoryTestResult{}, "CreateTime"))
+	assert.True(t, db.Migrator().HasIndex(&HistoryTestResult{}, historySuiteCaseIndex))
+
+	_, err = migrator.Down(2, false)
+	assert.NoError(t, err)
+	assert.True(t, db.Migrator().HasIndex(&HistoryTestResult{}, historySuiteCaseIndex))
+	var legacy []historyTestResultV1
+	assert.NoError(t, db.Order("id").Find(&legacy).Error)
+	if assert.Len(t, legacy, 2) {
+		assert.Empty(t, legacy[0].CreateTime)
+		assert.Equal(t, "2025-01-02T08:04:05.123456789", legacy[1].CreateTime)
+	}
+
+	// the invalid time is not converted
+	assert.NoError(t, db.Create(&historyTestResultV1{ID: "invalid", CreateTime: "yesterday"}).Error)
+	_, err = migrator.Up(0, false)
+	assert.ErrorContains(t, err, `history "invalid"`)
+	current, err := migrator.Current()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, current)
+	legacyColumn, err := isStringColumn(db, &historyTimeV3{}, "create_time")
+	assert.NoError(t, err)
+	assert.True(t, legacyColumn)
+}
+
+func TestResumeHistoryTimeConversion(t *testing.T) {
+	createTime := time.Date(2025, 1, 2, 0, 4, 5, 0, time.UTC)
+	openLegacy := func(t *testing.T) (migrator *SchemaMigrator, db *gorm.DB) {
+		migrator, err := OpenSchemaMigrator(&atest.Store{
+			Properties: map[string]string{
+				"driver":   "sqlite",
+				"database": filepath.Join(t.TempDir(), "history"),
+				"timezone": "Asia/Shanghai",
+			},
+		})
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		t.Cleanup(func() {
+			_ = migrator.Close()
+		})
+		db = migrator.db
+
+		_, err = migrator.Up(2, false)
+		assert.NoError(t, err)
+		assert.NoError(t, db.Create(&historyTestResultV1{ID: "old", CreateTime: "2025-01-02T08:04:05"}).Error)
+		return
+	}
+	model := &historyTimeV3{}
+
+	// the DDL of MySQL is committed implicitly, the partial conversions are made by hand here
+	upCases := []struct {
+		name    string
+		partial func(db *gorm.DB) error
+	}{{
+		name: "the UTC column is added",
+		partial: func(db *gorm.DB) error {
+			return db.Migrator().AddColumn(model, "UTCTime")
+		},
+	}, {
+		name: "the legacy column is dropped",
+		partial: func(db *gorm.DB) (err error) {
+			if err = db.Migrator().AddColumn(model, "UTCTime"); err == nil {
+				if err = db.Model(model).Where("id = ?", "old").Update("create_time_utc", createTime).Error; err == nil {
+					err = dropColumn(db, model.TableName(), "create_time")
+				}
+			}
+			return
+		},
+	}, {
+		name: "the index is not created",
+		partial: func(db *gorm.DB) (err error) {
+			if err = convertHistoryTimeToUTC(db); err == nil {
+				err = db.Migrator().DropIndex(model, "CreateTime")
+			}
+			return
+		},
+	}}
+	for _, tt := range upCases {
+		t.Run("up after "+tt.name, func(t *testing.T) {
+			migrator, db := openLegacy(t)
+			assert.NoError(t, tt.partial(db))
+
+			_, err := migrator.Up(0, false)
+			assert.NoError(t, err)
+			current, err := migrator.Current()
+			assert.NoError(t, err)
+			assert.Equal(t, 3, current)
+			assert.True(t, db.Migrator().HasIndex(&HistoryTestResult{}, "CreateTime"))
+			assert.False(t, db.Migrator().HasColumn(model, "create_time_utc"))
+			var record HistoryTestResult
+			assert.NoError(t, db.First(&record, "id = ?", "old").Error)
+			assert.Equal(t, createTime, record.CreateTime.UTC())
+		})
+	}
+
+	downCases := []struct {
+		name    string
+		partial func(db *gorm.DB) error
+	}{{
+		name: "the local column is added",
+		partial: func(db *gorm.DB) (err error) {
+			if err = db.Migrator().DropIndex(model, "CreateTime"); err == nil {
+				err = db.Migrator().AddColumn(model, "LocalTime")
+			}
+			return
+		},
+	}, {
+		name: "the UTC column is dropped",
+		partial: func(db *gorm.DB) (err error) {
+			if err = db.Migrator().DropIndex(model, "CreateTime"); err == nil {
+				if err = db.Migrator().AddColumn(model, "LocalTime"); err == nil {
+					if err = db.Model(model).Where("id = ?", "old").Update("create_time_local", "2025-01-02T08:04:05").Error; err == nil {
+						err = dropColumn(db, model.TableName(), "create_time")
+					}
+				}
+			}
+			return
+		},
+	}}
+	for _, tt := range downCases {
+		t.Run("down after "+tt.name, func(t *testing.T) {
+			migrator, db := openLegacy(t)
+			_, err := migrator.Up(0, false)
+			assert.NoError(t, err)
+			assert.NoError(t, tt.partial(db))
+
+			_, err = migrator.Down(2, false)
+			assert.NoError(t, err)
+			current, err := migrator.Current()
+			assert.NoError(t, err)
+			assert.Equal(t, 2, current)
+			assert.False(t, db.Migrator().HasColumn(model, "create_time_local"))
+			var legacy historyTestResultV1
+			assert.NoError(t, db.First(&legacy, "id = ?", "old").Error)
+			assert.Equal(t, "2025-01-02T08:04:05", legacy.CreateTime)
+		})
+	}
+}
+
+func TestOpenSchemaMigratorWithReplicas(t *testing.T) {
+	// the embedded databases reject the replicas, which are not used by the migrations
+	migrator, err := OpenSchemaMigrator(&atest.Store{
+		Properties: map[string]string{
+			"driver":   "sqlite",
+			"database": filepath.Join(t.TempDir(), "primary"),
+			"Replicas": filepath.Join(t.TempDir(), "replica"),
+		},
+	})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer migrator.Close()
+	assert.False(t, hasReplicas(migrator.db))
+}
+
+func TestMigrationsOf(t *testing.T) {
+	assert.Empty(t, migrationsOf("greptime"))
+	assert.NotEmpty(t, migrationsOf(DialectorMySQL))
+	assert.NotEmpty(t, migrationsOf(DialectorTDengine))
+}
+
+func TestIsAutoMigrate(t *testing.T) {
+	assert.True(t, isAutoMigrate(nil))
+	assert.True(t, isAutoMigrate(map[string]string{"automigrate": "invalid"}))
+	assert.False(t, isAutoMigrate(map[string]string{propAutoMigrate: "false"}))
+}
@@ -0,0 +1,551 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	propPageSize = "pageSize"
+	propMaxRows  = "maxRows"
+
+	hintPageSize  = "page_size"
+	hintPageToken = "page_token"
+)
+
+// defaultMaxRows is the max rows of all the result sets, which protects the memory from the huge tables
+const defaultMaxRows = 10000
+
+var (
+	// queryHint matches the comment which only has the hints, e.g. /* page_size=100, timeout=5s */
+	queryHint     = regexp.MustCompile(`/\*\+?(\s*\w+\s*=\s*[^\s,*]+\s*,?)+\s*\*/`)
+	queryHintPair = regexp.MustCompile(`(\w+)\s*=\s*([^\s,*]+)`)
+)
+
+// queryHints returns the hints in the comments of the SQL, the keys are in lower case
+func queryHints(sqlText string) (hints map[string]string) {
+	hints = map[string]string{}
+	for _, comment := range queryHint.FindAllString(sqlText, -1) {
+		for _, pair := range queryHintPair.FindAllStringSubmatch(comment, -1) {
+			hints[strings.ToLower(pair[1])] = pair[2]
+		}
+	}
+	return
+}
+
+// queryPaging represents the window of the rows returned from all the result sets
+type queryPaging struct {
+	// Cursor is the position of the page, which comes from the page token. Nil means the first page.
+	Cursor *pageCursor
+	// PageSize is the rows of a page, zero means no pagination
+	PageSize int
+	// MaxRows truncates the result sets, zero means no limit
+	MaxRows int
+	// sqlHash identifies the query of the page token
+	sqlHash string
+}
+
+// pageCursor locates the next page by the statement to continue, and the sort keys of the statement with
+// the values of the last row in the previous page. The statement starts from its first row without the keys.
+type pageCursor struct {
+	Statement int         `json:"s"`
+	Keys      []cursorKey `json:"k,omitempty"`
+	Hash      string      `json:"h"`
+}
+
+// cursorKey is a sort key of the statement, and its value of the last row in the previous page.
+// The key without the type only sorts the rows, e.g. a tiebreaker which is not needed to locate the page.
+type cursorKey struct {
+	Column string `json:"c"`
+	Desc   bool   `json:"d,omitempty"`
+	// Nulls is FIRST or LAST if the ORDER BY has it, otherwise the NULL values sort by the default of the dialect
+	Nulls string `json:"n,omitempty"`
+	Type  string `json:"t,omitempty"`
+	Value string `json:"v,omitempty"`
+}
+
+// nullType is the type of the NULL value of a key
+const nullType = "null"
+
+// parseQueryPaging reads the page size and max rows from the store properties, and the hints
+// page_size and page_token of the SQL. The hint overrides the store property.
+func parseQueryPaging(properties map[string]string, sqlText string) (paging queryPaging, err error) {
+	paging.MaxRows = defaultMaxRows
+	if _, ok := getProperty(properties, propMaxRows); ok {
+		if paging.MaxRows, err = parseIntProperty(properties, propMaxRows); err != nil {
+			return
+		}
+	}
+	if paging.PageSize, err = parseIntProperty(properties, propPageSize); err != nil {
+		return
+	}
+
+	hints := queryHints(sqlText)
+	if v, ok := hints[hintPageSize]; ok {
+		if paging.PageSize, err = strconv.Atoi(v); err != nil || paging.PageSize < 0 {
+			err = fmt.Errorf("invalid %s hint %q", hintPageSize, v)
+			return
+		}
+	}
+
+	paging.sqlHash = hashQuery(sqlText)
+	if token, ok := hints[hintPageToken]; ok {
+		paging.Cursor, err = decodePageToken(token, paging.sqlHash)
+	}
+	return
+}
+
+// limit returns the rows to return from all the result sets, zero means no limit
+func (p queryPaging) limit() (limit int) {
+	limit = p.PageSize
+	if p.MaxRows > 0 && (limit == 0 || limit > p.MaxRows) {
+		limit = p.MaxRows
+	}
+	return
+}
+
+// nextPageToken returns the token of the page which starts from the cursor
+func (p queryPaging) nextPageToken(cursor pageCursor) string {
+	cursor.Hash = p.sqlHash
+	data, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodePageToken(token, sqlHash string) (cursor *pageCursor, err error) {
+	var data []byte
+	if data, err = base64.RawURLEncoding.DecodeString(token); err == nil {
+		cursor = &pageCursor{}
+		if err = json.Unmarshal(data, cursor); err == nil && (cursor.Statement < 0 || cursor.Hash != sqlHash) {
+			err = errors.New("the page token does not belong to the query")
+		}
+		for i := 0; err == nil && i < len(cursor.Keys) && cursor.Keys[i].Type != ""; i++ {
+			_, err = cursor.Keys[i].value()
+		}
+	}
+	if err != nil {
+		cursor = nil
+		err = fmt.Errorf("invalid %s hint: %v", hintPageToken, err)
+	}
+	return
+}
+
+// hashQuery identifies the SQL without the hints, which changes between the pages
+func hashQuery(sqlText string) string {
+	hash := sha256.Sum256([]byte(strings.TrimSpace(queryHint.ReplaceAllString(sqlText, ""))))
+	return hex.EncodeToString(hash[:8])
+}
+
+// bind keeps the value of the key with its type, ok is false if the value is of a type which cannot be bound again
+func (k cursorKey) bind(val interface{}) (key cursorKey, ok bool) {
+	key = k
+	ok = true
+	switch v := val.(type) {
+	case nil:
+		key.Type, key.Value = nullType, ""
+	case time.Time:
+		key.Type, key.Value = "time", v.Format(time.RFC3339Nano)
+	case []byte:
+		key.Type, key.Value = "bytes", base64.StdEncoding.EncodeToString(v)
+	case bool:
+		key.Type, key.Value = "bool", strconv.FormatBool(v)
+	case fmt.Stringer:
+		// e.g. the decimals and UUIDs, which are compared with their text
+		key.Type, key.Value = "string", v.String()
+	default:
+		value := reflect.ValueOf(val)
+		switch value.Kind() {
+		case reflect.Pointer, reflect.Interface:
+			if value.IsNil() {
+				key, ok = k.bind(nil)
+			} else {
+				key, ok = k.bind(value.Elem().Interface())
+			}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+			key.Type, key.Value = "int", strconv.FormatInt(value.Int(), 10)
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			key.Type, key.Value = "uint", strconv.FormatUint(value.Uint(), 10)
+		case reflect.Float32, reflect.Float64:
+			key.Type, key.Value = "float", strconv.FormatFloat(value.Float(), 'g', -1, 64)
+		case reflect.String:
+			key.Type, key.Value = "string", value.String()
+		default:
+			ok = false
+		}
+	}
+	return
+}
+
+// value returns the value of the key to bind
+func (k cursorKey) value() (val interface{}, err error) {
+	switch k.Type {
+	case nullType:
+	case "time":
+		val, err = time.Parse(time.RFC3339Nano, k.Value)
+	case "bytes":
+		val, err = base64.StdEncoding.DecodeString(k.Value)
+	case "bool":
+		val, err = strconv.ParseBool(k.Value)
+	case "int":
+		val, err = strconv.ParseInt(k.Value, 10, 64)
+	case "uint":
+		val, err = strconv.ParseUint(k.Value, 10, 64)
+	case "float":
+		val, err = strconv.ParseFloat(k.Value, 64)
+	case "string":
+		val = k.Value
+	default:
+		err = fmt.Errorf("unknown type %q of the key %q", k.Type, k.Column)
+	}
+	return
+}
+
+// nullsFirst tells if the NULL values of the key sort before the others
+func (k cursorKey) nullsFirst(dialect string) bool {
+	switch {
+	case k.Nulls != "":
+		return k.Nulls == "FIRST"
+	case dialect == DialectorPostgres:
+		// NULL is larger than any value
+		return k.Desc
+	case dialect == DialectorDuckDB || dialect == DialectorClickHouse:
+		return false
+	default:
+		// NULL is smaller than any value
+		return !k.Desc
+	}
+}
+
+// orderKey is a sort key of the top-level ORDER BY, which is either a column name or the position of a column
+type orderKey struct {
+	name     string
+	position int
+	desc     bool
+	// nulls is FIRST or LAST of the NULLS option
+	nulls string
+}
+
+// orderByEnds are the clauses which might follow the ORDER BY
+var orderByEnds = []string{"LIMIT", "OFFSET", "FETCH", "FOR", "SETTINGS", "FORMAT", "WITH"}
+
+// parseOrderBy returns the keys of the top-level ORDER BY of the statement, and the statement without it
+// if nothing follows the ORDER BY, e.g. a LIMIT. The keys are nil if the statement has no ORDER BY, or any
+// of them is not a plain column, e.g. an expression or a qualified column like u.id.
+func parseOrderBy(statement, dialect string) (keys []orderKey, unordered string) {
+	unordered = statement
+	tokens := topLevelTokens(statement, dialect)
+	start := -1
+	for i := len(tokens) - 2; i >= 0 && start < 0; i-- {
+		if strings.EqualFold(tokens[i].text, "ORDER") && strings.EqualFold(tokens[i+1].text, "BY") {
+			start = i
+		}
+	}
+	if start < 0 {
+		return
+	}
+
+	end := len(tokens)
+	var items [][]sqlToken
+	item := []sqlToken{}
+	for i := start + 2; i < len(tokens); i++ {
+		if slices.ContainsFunc(orderByEnds, func(word string) bool {
+			return strings.EqualFold(tokens[i].text, word)
+		}) {
+			end = i
+			break
+		}
+		if tokens[i].text == "," {
+			items, item = append(items, item), []sqlToken{}
+		} else {
+			item = append(item, tokens[i])
+		}
+	}
+	items = append(items, item)
+
+	for _, item := range items {
+		key, ok := parseOrderKey(item, dialect)
+		if !ok {
+			keys = nil
+			return
+		}
+		keys = append(keys, key)
+	}
+	// the ORDER BY of the rows limited by TOP is kept, which is only allowed in the subquery with it
+	if end == len(tokens) && !slices.ContainsFunc(tokens[:start], func(token sqlToken) bool {
+		return strings.EqualFold(token.text, "TOP")
+	}) {
+		unordered = strings.TrimSpace(statement[:tokens[start].start])
+	}
+	return
+}
+
+// parseOrderKey parses the sort key like id, `id` DESC, 2 ASC or name NULLS LAST
+func parseOrderKey(tokens []sqlToken, dialect string) (key orderKey, ok bool) {
+	if len(tokens) == 0 {
+		return
+	}
+	name := tokens[0].text
+	switch {
+	case tokens[0].quoted:
+		// the double quotes are strings in MySQL
+		if key.name = name[1 : len(name)-1]; name[0] == '\'' || name[0] == '"' && dialect == DialectorMySQL {
+			return
+		}
+	case isIdentifierChar(name, 0):
+		if position, err := strconv.Atoi(name); err == nil {
+			key.position = position
+		} else {
+			key.name = name
+		}
+	default:
+		return
+	}
+
+	rest := tokens[1:]
+	if len(rest) > 0 && (strings.EqualFold(rest[0].text, "ASC") || strings.EqualFold(rest[0].text, "DESC")) {
+		key.desc = strings.EqualFold(rest[0].text, "DESC")
+		rest = rest[1:]
+	}
+	if len(rest) == 2 && strings.EqualFold(rest[0].text, "NULLS") &&
+		(strings.EqualFold(rest[1].text, "FIRST") || strings.EqualFold(rest[1].text, "LAST")) {
+		key.nulls = strings.ToUpper(rest[1].text)
+		rest = nil
+	}
+	ok = len(rest) == 0
+	return
+}
+
+// sqlToken is a word, quoted text or symbol outside the parentheses
+type sqlToken struct {
+	text   string
+	start  int
+	quoted bool
+}
+
+// topLevelTokens returns the tokens outside the parentheses, the comments are dropped
+func topLevelTokens(statement, dialect string) (tokens []sqlToken) {
+	opts := splitOptionsOf(dialect)
+	depth := 0
+	for i := 0; i < len(statement); {
+		rest := statement[i:]
+		end := skippedEnd(statement, i, opts)
+		if end > 0 {
+			if depth == 0 && !isCommentStart(rest, opts) {
+				tokens = append(tokens, sqlToken{text: rest[:end], start: i, quoted: true})
+			}
+			i += end
+			continue
+		}
+
+		end = 1
+		switch c := rest[0]; {
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case strings.ContainsRune(" \t\r\n", rune(c)):
+		case depth > 0:
+		case isIdentifierChar(rest, 0):
+			for end < len(rest) && isIdentifierChar(rest, end) {
+				end++
+			}
+			tokens = append(tokens, sqlToken{text: rest[:end], start: i})
+		default:
+			tokens = append(tokens, sqlToken{text: rest[:1], start: i})
+		}
+		i += end
+	}
+	return
+}
+
+// pagingStatements are the statements which can be wrapped by a keyset condition
+var pagingStatements = []string{"SELECT", "WITH"}
+
+// canPaginate tells if the statement might be paginated by the keys of its ORDER BY
+func canPaginate(statement, dialect string) bool {
+	keyword, _ := classifyStatement(statement, dialect)
+	if !slices.Contains(pagingStatements, keyword) || (keyword == "WITH" && dialect == DialectorSQLServer) {
+		// SQL Server does not allow a CTE in the subquery
+		return false
+	}
+	keys, _ := parseOrderBy(statement, dialect)
+	return keys != nil
+}
+
+// unorderableTypes are the database types which cannot be compared, so they are not the tiebreakers
+var unorderableTypes = map[string][]string{
+	DialectorPostgres:   {"JSON", "XML", "POINT", "LINE", "LSEG", "BOX", "PATH", "POLYGON", "CIRCLE"},
+	DialectorSQLServer:  {"TEXT", "NTEXT", "IMAGE", "XML", "GEOMETRY", "GEOGRAPHY"},
+	DialectorClickHouse: {"JSON", "OBJECT", "MAP"},
+}
+
+func isOrderable(column *columnMeta, dialect string) bool {
+	return column.Name != "" && !slices.ContainsFunc(unorderableTypes[dialect], func(databaseType string) bool {
+		return column.DatabaseType == databaseType || strings.HasPrefix(column.DatabaseType, databaseType+"(")
+	})
+}
+
+// pageOrder returns the sort keys to paginate the statement, which are the keys of its ORDER BY followed by
+// the other orderable columns as the tiebreakers, so the rows which have the same ORDER BY keys are not skipped
+// between the pages. The keys are nil if the statement cannot be paginated, e.g. it has no ORDER BY, a key is
+// not a column of the result, or the names of the columns are not unique.
+func pageOrder(statement, dialect string, columns []*columnMeta) (order []cursorKey) {
+	if !canPaginate(statement, dialect) {
+		return
+	}
+	names := columnNames(columns)
+	indexOf := func(name string) int {
+		return slices.IndexFunc(names, func(column string) bool {
+			return strings.EqualFold(column, name)
+		})
+	}
+	for i, name := range names {
+		if indexOf(name) != i {
+			return
+		}
+	}
+
+	sorted := make([]bool, len(columns))
+	keys, _ := parseOrderBy(statement, dialect)
+	for _, key := range keys {
+		index := key.position - 1
+		if key.name != "" {
+			index = indexOf(key.name)
+		}
+		if index < 0 || index >= len(columns) || names[index] == "" {
+			return nil
+		}
+		if !sorted[index] {
+			sorted[index] = true
+			order = append(order, cursorKey{Column: names[index], Desc: key.desc, Nulls: key.nulls})
+		}
+	}
+	for i, column := range columns {
+		if !sorted[i] && isOrderable(column, dialect) {
+			order = append(order, cursorKey{Column: column.Name})
+		}
+	}
+	return
+}
+
+// keysetStatement wraps the statement to read the rows after the keys which have the values, and sorts the rows
+// by all the keys, e.g.
+// SELECT * FROM (<statement>) atest_page WHERE (a > ?) OR (a = ? AND (b < ? OR b IS NULL)) ORDER BY a, b DESC, c
+func keysetStatement(statement string, args []interface{}, keys []cursorKey, db *gorm.DB) (sqlText string, vars []interface{}, err error) {
+	dialect := db.Dialector.Name()
+	stmt := &gorm.Statement{DB: db, Vars: slices.Clone(args)}
+	var builder strings.Builder
+	// the line comment at the end of the statement must not comment out the parenthesis
+	builder.WriteString("SELECT * FROM (\n")
+	builder.WriteString(statement)
+	builder.WriteString("\n) atest_page")
+
+	var bound, branches int
+	for bound < len(keys) && keys[bound].Type != "" {
+		bound++
+	}
+	for i := 0; i < bound; i++ {
+		if keys[i].Type == nullType && !keys[i].nullsFirst(dialect) {
+			// nothing is after the NULL value which sorts last
+			continue
+		}
+		if branches++; branches == 1 {
+			builder.WriteString(" WHERE (")
+		} else {
+			builder.WriteString(" OR (")
+		}
+		for j := 0; j <= i; j++ {
+			if j > 0 {
+				builder.WriteString(" AND ")
+			}
+			if err = writeKeyCondition(&builder, stmt, keys[j], j == i); err != nil {
+				return
+			}
+		}
+		builder.WriteString(")")
+	}
+	if bound > 0 && branches == 0 {
+		builder.WriteString(" WHERE 1 = 0")
+	}
+
+	builder.WriteString(" ORDER BY ")
+	for i, key := range keys {
+		if i > 0 {
+			builder.WriteString(", ")
+		}
+		db.Dialector.QuoteTo(&builder, key.Column)
+		if key.Desc {
+			builder.WriteString(" DESC")
+		}
+		if key.Nulls != "" {
+			builder.WriteString(" NULLS " + key.Nulls)
+		}
+	}
+	sqlText, vars = builder.String(), stmt.Vars
+	return
+}
+
+// writeKeyCondition writes the condition of the rows which have the same value of the key, or the rows after it
+func writeKeyCondition(builder *strings.Builder, stmt *gorm.Statement, key cursorKey, after bool) (err error) {
+	var val interface{}
+	if val, err = key.value(); err != nil {
+		return
+	}
+	dialector := stmt.DB.Dialector
+	// the NULL values which sort last are after any value
+	nullsAfter := after && val != nil && !key.nullsFirst(dialector.Name())
+	if nullsAfter {
+		builder.WriteString("(")
+	}
+	dialector.QuoteTo(builder, key.Column)
+	switch {
+	case val == nil && after:
+		builder.WriteString(" IS NOT NULL")
+	case val == nil:
+		builder.WriteString(" IS NULL")
+	case after:
+		operator := " > "
+		if key.Desc {
+			operator = " < "
+		}
+		builder.WriteString(operator)
+		stmt.Vars = append(stmt.Vars, val)
+		dialector.BindVarTo(builder, stmt, val)
+		if nullsAfter {
+			builder.WriteString(" OR ")
+			dialector.QuoteTo(builder, key.Column)
+			builder.WriteString(" IS NULL)")
+		}
+	default:
+		builder.WriteString(" = ")
+		stmt.Vars = append(stmt.Vars, val)
+		dialector.BindVarTo(builder, stmt, val)
+	}
+	return
+}
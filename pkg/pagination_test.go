@@ -0,0 +1,505 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/linuxsuren/api-testing/pkg/server"
+	atest "github.com/linuxsuren/api-testing/pkg/testing"
+	"github.com/linuxsuren/api-testing/pkg/testing/remote"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func TestQueryHints(t *testing.T) {
+	assert.Equal(t, map[string]string{"page_size": "10", "page_token": "abc", "timeout": "5s"},
+		queryHints("/* page_size=10, page_token=abc */ SELECT /*+ TIMEOUT = 5s */ 1"))
+	assert.Empty(t, queryHints("SELECT 1 /* a comment, not a = hint */"))
+	assert.Equal(t, hashQuery("SELECT 1"), hashQuery("/* page_token=abc */ SELECT 1 "))
+}
+
+func TestParseQueryPaging(t *testing.T) {
+	const sqlText = "SELECT * FROM t"
+	cursor := pageCursor{Statement: 1, Keys: []cursorKey{{Column: "id", Desc: true, Type: "int", Value: "10"}}}
+	next := queryPaging{PageSize: 10, sqlHash: hashQuery(sqlText)}.nextPageToken(cursor)
+	cursor.Hash = hashQuery(sqlText)
+
+	tests := []struct {
+		name       string
+		properties map[string]string
+		sql        string
+		expect     queryPaging
+		hasErr     bool
+	}{{
+		name:   "default",
+		sql:    sqlText,
+		expect: queryPaging{MaxRows: defaultMaxRows},
+	}, {
+		name:       "store properties",
+		properties: map[string]string{propPageSize: "20", propMaxRows: "0"},
+		sql:        sqlText,
+		expect:     queryPaging{PageSize: 20},
+	}, {
+		name:       "hints",
+		properties: map[string]string{propPageSize: "20"},
+		sql:        fmt.Sprintf("/* page_size=10, page_token=%s */ %s", next, sqlText),
+		expect:     queryPaging{Cursor: &cursor, PageSize: 10, MaxRows: defaultMaxRows},
+	}, {
+		name:   "token of another query",
+		sql:    fmt.Sprintf("/* page_token=%s */ SELECT * FROM other", next),
+		hasErr: true,
+	}, {
+		name:   "invalid token",
+		sql:    "/* page_token=fake */ " + sqlText,
+		hasErr: true,
+	}, {
+		name: "invalid key of the token",
+		sql: fmt.Sprintf("/* page_token=%s */ %s", queryPaging{sqlHash: hashQuery(sqlText)}.nextPageToken(
+			pageCursor{Keys: []cursorKey{{Column: "id", Type: "int", Value: "fake"}}}), sqlText),
+		hasErr: true,
+	}, {
+		name:   "invalid page size",
+		sql:    "/* page_size=-1 */ " + sqlText,
+		hasErr: true,
+	}, {
+		name:       "invalid max rows",
+		properties: map[string]string{propMaxRows: "fake"},
+		sql:        sqlText,
+		hasErr:     true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			paging, err := parseQueryPaging(tt.properties, tt.sql)
+			if tt.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				paging.sqlHash = ""
+				assert.Equal(t, tt.expect, paging)
+			}
+		})
+	}
+}
+
+func TestParseOrderBy(t *testing.T) {
+	tests := []struct {
+		name      string
+		dialect   string
+		sql       string
+		keys      []orderKey
+		unordered string
+	}{{
+		name:      "columns",
+		sql:       "SELECT * FROM t ORDER BY a, `b` DESC, 3 ASC",
+		dialect:   DialectorMySQL,
+		keys:      []orderKey{{name: "a"}, {name: "b", desc: true}, {position: 3}},
+		unordered: "SELECT * FROM t",
+	}, {
+		name:      "nulls",
+		sql:       `SELECT * FROM t ORDER BY "a" DESC NULLS LAST -- the last`,
+		dialect:   DialectorPostgres,
+		keys:      []orderKey{{name: "a", desc: true, nulls: "LAST"}},
+		unordered: "SELECT * FROM t",
+	}, {
+		name:      "with limit",
+		sql:       "SELECT * FROM t ORDER BY a LIMIT 10",
+		keys:      []orderKey{{name: "a"}},
+		unordered: "SELECT * FROM t ORDER BY a LIMIT 10",
+	}, {
+		name:      "in the subquery",
+		sql:       "SELECT * FROM (SELECT * FROM t ORDER BY a) s",
+		unordered: "SELECT * FROM (SELECT * FROM t ORDER BY a) s",
+	}, {
+		name:      "top",
+		sql:       "SELECT TOP 10 * FROM t ORDER BY [a]",
+		dialect:   DialectorSQLServer,
+		keys:      []orderKey{{name: "a"}},
+		unordered: "SELECT TOP 10 * FROM t ORDER BY [a]",
+	}, {
+		name:      "expression",
+		sql:       "SELECT * FROM t ORDER BY a + 1",
+		unordered: "SELECT * FROM t ORDER BY a + 1",
+	}, {
+		name:      "qualified column",
+		sql:       "SELECT * FROM t ORDER BY t.a",
+		unordered: "SELECT * FROM t ORDER BY t.a",
+	}, {
+		name:      "string in MySQL",
+		sql:       `SELECT * FROM t ORDER BY "a"`,
+		dialect:   DialectorMySQL,
+		unordered: `SELECT * FROM t ORDER BY "a"`,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keys, unordered := parseOrderBy(tt.sql, tt.dialect)
+			assert.Equal(t, tt.keys, keys)
+			assert.Equal(t, tt.unordered, unordered)
+		})
+	}
+}
+
+func TestPageOrder(t *testing.T) {
+	columns := []*columnMeta{{Name: "id"}, {Name: "name"}, {Name: "doc", DatabaseType: "JSON"}, {Name: "score"}}
+	assert.Equal(t, []cursorKey{{Column: "name", Desc: true, Nulls: "FIRST"}, {Column: "id"}, {Column: "score"}},
+		pageOrder("SELECT * FROM t ORDER BY NAME DESC NULLS FIRST, 1, name", DialectorPostgres, columns))
+	assert.Equal(t, []cursorKey{{Column: "id"}, {Column: "name"}, {Column: "doc"}, {Column: "score"}},
+		pageOrder("SELECT * FROM t ORDER BY id", DialectorMySQL, columns))
+
+	for _, sqlText := range []string{
+		"SELECT * FROM t",
+		"SELECT * FROM t ORDER BY other",
+		"SELECT * FROM t ORDER BY 5",
+		"DELETE FROM t ORDER BY id LIMIT 1",
+	} {
+		assert.Nil(t, pageOrder(sqlText, DialectorMySQL, columns), sqlText)
+	}
+	assert.Nil(t, pageOrder("SELECT * FROM t ORDER BY id", DialectorMySQL, []*columnMeta{{Name: "id"}, {Name: "ID"}}))
+}
+
+func TestKeysetStatement(t *testing.T) {
+	db := &gorm.DB{Config: &gorm.Config{Dialector: postgres.New(postgres.Config{})}}
+	sqlText, vars, err := keysetStatement("SELECT * FROM t WHERE a > $1 -- the filter", []interface{}{1}, []cursorKey{
+		{Column: "a", Type: "int", Value: "5"},
+		{Column: "b", Desc: true, Type: "string", Value: "x"},
+		{Column: "c"},
+	}, db)
+	assert.NoError(t, err)
+	assert.Equal(t, "SELECT * FROM (\nSELECT * FROM t WHERE a > $1 -- the filter\n) atest_page "+
+		`WHERE (("a" > $2 OR "a" IS NULL)) OR ("a" = $3 AND "b" < $4) ORDER BY "a", "b" DESC, "c"`, sqlText)
+	assert.Equal(t, []interface{}{1, int64(5), int64(5), "x"}, vars)
+
+	t.Run("first page", func(t *testing.T) {
+		sqlText, vars, err := keysetStatement("SELECT * FROM t", nil, []cursorKey{{Column: "a", Nulls: "LAST"}, {Column: "b"}}, db)
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM (\nSELECT * FROM t\n) atest_page ORDER BY \"a\" NULLS LAST, \"b\"", sqlText)
+		assert.Empty(t, vars)
+	})
+
+	t.Run("null", func(t *testing.T) {
+		sqlText, vars, err := keysetStatement("SELECT * FROM t", nil, []cursorKey{
+			{Column: "a", Desc: true, Type: nullType},
+			{Column: "b", Type: "int", Value: "1"},
+		}, db)
+		assert.NoError(t, err)
+		assert.Equal(t, "SELECT * FROM (\nSELECT * FROM t\n) atest_page "+
+			`WHERE ("a" IS NOT NULL) OR ("a" IS NULL AND ("b" > $1 OR "b" IS NULL)) ORDER BY "a" DESC, "b"`, sqlText)
+		assert.Equal(t, []interface{}{int64(1)}, vars)
+
+		// nothing is after the NULL value which sorts last
+		sqlText, _, err = keysetStatement("SELECT * FROM t", nil, []cursorKey{{Column: "a", Type: nullType}}, db)
+		assert.NoError(t, err)
+		assert.Contains(t, sqlText, "WHERE 1 = 0")
+	})
+
+	_, _, err = keysetStatement("SELECT * FROM t", nil, []cursorKey{{Column: "a", Type: "fake"}}, db)
+	assert.Error(t, err)
+}
+
+func TestCursorKey(t *testing.T) {
+	now := time.Date(2025, 1, 2, 3, 4, 5, 6, time.UTC)
+	name := "alice"
+	var null *string
+	for _, val := range []interface{}{int32(-1), uint8(1), 1.5, "a", &name, true, now, []byte("b"), nil, null} {
+		key, ok := cursorKey{Column: "c"}.bind(val)
+		assert.True(t, ok)
+		value, err := key.value()
+		assert.NoError(t, err)
+		if val == nil || val == null {
+			assert.Nil(t, value)
+		} else {
+			assert.EqualValues(t, reflect.Indirect(reflect.ValueOf(val)).Interface(), value)
+		}
+	}
+
+	_, ok := cursorKey{Column: "c"}.bind([]int{1})
+	assert.False(t, ok)
+}
+
+func TestNextCursor(t *testing.T) {
+	order := []cursorKey{{Column: "g"}, {Column: "n", Type: "string", Value: "stale"}}
+	columns := []string{"n", "g"}
+	// the group key tells the rows apart
+	assert.Equal(t, &pageCursor{Statement: 1, Keys: []cursorKey{{Column: "g", Type: "int", Value: "1"}, {Column: "n"}}},
+		nextCursor(order, 1, columns, []interface{}{"b", int64(1)}, []interface{}{"c", int64(2)}))
+	// the tiebreaker tells the rows apart
+	assert.Equal(t, &pageCursor{Keys: []cursorKey{{Column: "g", Type: "int", Value: "1"}, {Column: "n", Type: "string", Value: "b"}}},
+		nextCursor(order, 0, columns, []interface{}{"b", int64(1)}, []interface{}{"c", int64(1)}))
+	// the same rows
+	assert.Nil(t, nextCursor(order, 0, columns, []interface{}{"b", int64(1)}, []interface{}{"b", int64(1)}))
+	assert.Nil(t, nextCursor(nil, 0, columns, []interface{}{"b", int64(1)}, []interface{}{"c", int64(2)}))
+}
+
+func labelOf(labels []*server.Pair, key string) string {
+	for _, label := range labels {
+		if label.Key == key {
+			return label.Value
+		}
+	}
+	return ""
+}
+
+func TestQueryPagination(t *testing.T) {
+	remoteServer := NewRemoteServer(10, "")
+	newContext := func(properties map[string]string) context.Context {
+		properties["driver"] = driverSQLitePureGo
+		properties["database"] = "query_pagination"
+		return remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
+			Name:       t.Name(),
+			URL:        SQLiteMemory,
+			Properties: properties,
+		})
+	}
+	const numbers = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c LIMIT 25) SELECT x FROM c"
+	// readPages follows the page tokens, and returns the values of the first column in all the pages
+	readPages := func(t *testing.T, ctx context.Context, sqlText string) (values []string, pages int) {
+		query := sqlText
+		for ; pages < 10; pages++ {
+			result, err := remoteServer.Query(ctx, &server.DataQuery{Sql: query})
+			if !assert.NoError(t, err) {
+				break
+			}
+			for _, item := range result.Items {
+				values = append(values, item.Data[0].Value)
+			}
+			assert.Empty(t, labelOf(result.Meta.Labels, "_truncated"))
+			token := labelOf(result.Meta.Labels, "_next_page_token")
+			if token == "" {
+				pages++
+				break
+			}
+			query = fmt.Sprintf("/* page_token=%s */ %s", token, sqlText)
+		}
+		return
+	}
+
+	t.Run("pages", func(t *testing.T) {
+		values, pages := readPages(t, newContext(map[string]string{propPageSize: "10"}), numbers+" ORDER BY x")
+		assert.Equal(t, 3, pages)
+		assert.Len(t, values, 25)
+		assert.Equal(t, "1", values[0])
+		assert.Equal(t, "25", values[24])
+	})
+
+	t.Run("descending pages", func(t *testing.T) {
+		values, pages := readPages(t, newContext(map[string]string{propPageSize: "5"}), numbers+" ORDER BY 1 DESC")
+		assert.Equal(t, 5, pages)
+		assert.Len(t, values, 25)
+		assert.Equal(t, "25", values[0])
+		assert.Equal(t, "1", values[24])
+	})
+
+	t.Run("pages across the statements", func(t *testing.T) {
+		ctx := newContext(map[string]string{propPageSize: "10"})
+		sqlText := numbers + " ORDER BY x;\nSELECT 'a' AS name UNION ALL SELECT 'b' ORDER BY name"
+		values, pages := readPages(t, ctx, sqlText)
+		assert.Equal(t, 3, pages)
+		assert.Len(t, values, 27)
+		assert.Equal(t, []string{"25", "a", "b"}, values[24:])
+
+		// the page has the rows of both statements, and the offsets count all of them
+		result, err := remoteServer.Query(ctx, &server.DataQuery{Sql: fmt.Sprintf("/* page_token=%s */ %s",
+			queryPaging{sqlHash: hashQuery(sqlText)}.nextPageToken(pageCursor{
+				Keys: []cursorKey{{Column: "x", Type: "int", Value: "20"}},
+			}), sqlText)})
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 7)
+		var sets []resultSet
+		assert.NoError(t, json.Unmarshal([]byte(labelOf(result.Meta.Labels, "_result_sets")), &sets))
+		if assert.Len(t, sets, 2) {
+			assert.Equal(t, []int{0, 5}, []int{sets[0].Offset, sets[1].Offset})
+			assert.Equal(t, []int{5, 2}, []int{sets[0].Rows, sets[1].Rows})
+		}
+	})
+
+	t.Run("max rows of all the statements", func(t *testing.T) {
+		result, err := remoteServer.Query(newContext(map[string]string{propMaxRows: "30"}),
+			&server.DataQuery{Sql: numbers + ";" + numbers})
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 30)
+		assert.Equal(t, "true", labelOf(result.Meta.Labels, "_truncated"))
+	})
+
+	t.Run("duplicate order by values", func(t *testing.T) {
+		// the rows of the same group are told apart by the other columns
+		values, pages := readPages(t, newContext(map[string]string{propPageSize: "2"}),
+			"SELECT n, g FROM (SELECT 1 g,'c' n UNION ALL SELECT 1,'a' UNION ALL SELECT 1,'b' UNION ALL SELECT 2,'d') ORDER BY g")
+		assert.Equal(t, 2, pages)
+		assert.Equal(t, []string{"a", "b", "c", "d"}, values)
+	})
+
+	t.Run("null values", func(t *testing.T) {
+		values, _ := readPages(t, newContext(map[string]string{propPageSize: "2"}),
+			"SELECT n, g FROM (SELECT NULL g,'a' n UNION ALL SELECT 1,'b' UNION ALL SELECT NULL,'c' UNION ALL SELECT 2,NULL "+
+				"UNION ALL SELECT 1,'e') ORDER BY g DESC")
+		assert.Equal(t, []string{"null", "b", "e", "a", "c"}, values)
+	})
+
+	t.Run("duplicate rows", func(t *testing.T) {
+		result, err := remoteServer.Query(newContext(map[string]string{propPageSize: "2"}), &server.DataQuery{
+			Sql: "SELECT 1 AS g UNION ALL SELECT 2 UNION ALL SELECT 2 ORDER BY g",
+		})
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 2)
+		assert.Equal(t, "true", labelOf(result.Meta.Labels, "_truncated"))
+		assert.Empty(t, labelOf(result.Meta.Labels, "_next_page_token"))
+	})
+
+	t.Run("without order by", func(t *testing.T) {
+		result, err := remoteServer.Query(newContext(map[string]string{propPageSize: "10"}), &server.DataQuery{Sql: numbers})
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 10)
+		assert.Equal(t, "true", labelOf(result.Meta.Labels, "_truncated"))
+		assert.Empty(t, labelOf(result.Meta.Labels, "_next_page_token"))
+	})
+
+	t.Run("writes", func(t *testing.T) {
+		ctx := newContext(map[string]string{propPageSize: "10"})
+		sqlText := "CREATE TABLE IF NOT EXISTS paged (x INTEGER);\n" + numbers + " ORDER BY x"
+		result, err := remoteServer.Query(ctx, &server.DataQuery{Sql: sqlText})
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 10)
+		assert.Equal(t, "true", labelOf(result.Meta.Labels, "_truncated"))
+		assert.Empty(t, labelOf(result.Meta.Labels, "_next_page_token"))
+
+		// the writes before the page must not run again
+		token := queryPaging{sqlHash: hashQuery(sqlText)}.nextPageToken(pageCursor{Statement: 1})
+		_, err = remoteServer.Query(ctx, &server.DataQuery{Sql: fmt.Sprintf("/* page_token=%s */ %s", token, sqlText)})
+		assert.ErrorContains(t, err, "only for the read-only queries")
+	})
+
+	t.Run("statement out of range", func(t *testing.T) {
+		token := queryPaging{sqlHash: hashQuery(numbers)}.nextPageToken(pageCursor{Statement: 1})
+		_, err := remoteServer.Query(newContext(map[string]string{propPageSize: "10"}),
+			&server.DataQuery{Sql: fmt.Sprintf("/* page_token=%s */ %s", token, numbers)})
+		assert.Error(t, err)
+	})
+
+	t.Run("max rows", func(t *testing.T) {
+		result, err := remoteServer.Query(newContext(map[string]string{propMaxRows: "5"}), &server.DataQuery{Sql: numbers})
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 5)
+		assert.Equal(t, "true", labelOf(result.Meta.Labels, "_truncated"))
+		assert.Empty(t, labelOf(result.Meta.Labels, "_next_page_token"))
+	})
+
+	t.Run("within max rows", func(t *testing.T) {
+		result, err := remoteServer.Query(newContext(map[string]string{}), &server.DataQuery{Sql: numbers})
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 25)
+		for _, item := range result.Items {
+			// every row has its own pairs
+			assert.Len(t, item.Data, 1)
+		}
+		assert.Empty(t, labelOf(result.Meta.Labels, "_truncated"))
+	})
+
+	t.Run("stream", func(t *testing.T) {
+		var rows int
+		meta, err := remoteServer.(QueryStreamer).StreamQuery(newContext(map[string]string{propMaxRows: "5"}),
+			&server.DataQuery{Sql: numbers}, func(row *server.Pairs) error {
+				rows++
+				return nil
+			})
+		assert.NoError(t, err)
+		assert.Equal(t, 25, rows)
+		assert.Equal(t, `["x"]`, labelOf(meta.Labels, "_columns"))
+		assert.NotEmpty(t, meta.Duration)
+	})
+
+	t.Run("stream stops on error", func(t *testing.T) {
+		_, err := remoteServer.(QueryStreamer).StreamQuery(newContext(map[string]string{}),
+			&server.DataQuery{Sql: numbers}, func(row *server.Pairs) error {
+				return context.Canceled
+			})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
+
+// BenchmarkLargeTable shows the peak heap of reading a large table,
+// which stays bounded with the max rows, pagination and streaming.
+func BenchmarkLargeTable(b *testing.B) {
+	const total = 200000
+	db, err := gorm.Open(openSQLite(driverSQLitePureGo, filepath.Join(b.TempDir(), "large.db")), &gorm.Config{})
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err = db.Exec("CREATE TABLE large AS WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c LIMIT ?) "+
+		"SELECT x AS id, printf('name-%08d', x) AS name, x * 1.5 AS score FROM c", total).Error; err != nil {
+		b.Fatal(err)
+	}
+	const sqlText = "SELECT * FROM large ORDER BY id"
+	ctx := context.TODO()
+
+	var peak uint64
+	samplePeak := func() {
+		var stats runtime.MemStats
+		runtime.ReadMemStats(&stats)
+		peak = max(peak, stats.HeapAlloc)
+	}
+	run := func(b *testing.B, do func() error) {
+		b.ReportAllocs()
+		runtime.GC()
+		peak = 0
+		for i := 0; i < b.N; i++ {
+			if err := do(); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(peak)/(1<<20), "peak-MiB")
+	}
+
+	b.Run("max rows", func(b *testing.B) {
+		run(b, func() error {
+			_, err := runMultilineSQL(ctx, sqlText, db, queryPaging{MaxRows: defaultMaxRows})
+			samplePeak()
+			return err
+		})
+	})
+
+	b.Run("page", func(b *testing.B) {
+		paging := queryPaging{PageSize: 100, MaxRows: defaultMaxRows, Cursor: &pageCursor{
+			Keys: []cursorKey{{Column: "id", Type: "int", Value: strconv.Itoa(total / 2)}},
+		}}
+		run(b, func() error {
+			_, err := runMultilineSQL(ctx, sqlText, db, paging)
+			samplePeak()
+			return err
+		})
+	})
+
+	b.Run("stream", func(b *testing.B) {
+		run(b, func() error {
+			var rows int
+			_, err := streamMultilineSQL(ctx, sqlText, db, queryPaging{}, func(*server.Pairs) error {
+				if rows++; rows%10000 == 0 {
+					samplePeak()
+				}
+				return nil
+			})
+			return err
+		})
+	})
+}
@@ -0,0 +1,111 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	propMaxOpenConns    = "maxOpenConns"
+	propMaxIdleConns    = "maxIdleConns"
+	propConnMaxLifetime = "connMaxLifetime"
+	propConnMaxIdleTime = "connMaxIdleTime"
+)
+
+// poolOptions represents the connection pool settings of a store.
+// The zero value keeps the database/sql defaults.
+type poolOptions struct {
+	MaxOpenConns    int
+	MaxIdleConns    int
+	ConnMaxLifetime time.Duration
+	ConnMaxIdleTime time.Duration
+}
+
+func parsePoolOptions(properties map[string]string) (opts poolOptions, err error) {
+	if opts.MaxOpenConns, err = parseIntProperty(properties, propMaxOpenConns); err != nil {
+		return
+	}
+	if opts.MaxIdleConns, err = parseIntProperty(properties, propMaxIdleConns); err != nil {
+		return
+	}
+	if opts.ConnMaxLifetime, err = parseDurationProperty(properties, propConnMaxLifetime); err != nil {
+		return
+	}
+	if opts.ConnMaxIdleTime, err = parseDurationProperty(properties, propConnMaxIdleTime); err != nil {
+		return
+	}
+
+	if opts.MaxOpenConns > 0 && opts.MaxIdleConns > opts.MaxOpenConns {
+		err = fmt.Errorf("%s(%d) cannot be greater than %s(%d)", propMaxIdleConns, opts.MaxIdleConns,
+			propMaxOpenConns, opts.MaxOpenConns)
+	}
+	return
+}
+
+func parseIntProperty(properties map[string]string, key string) (val int, err error) {
+	if v, ok := getProperty(properties, key); ok && v != "" {
+		if val, err = strconv.Atoi(v); err != nil {
+			err = fmt.Errorf("failed to parse %s: %v", key, err)
+		} else if val < 0 {
+			err = fmt.Errorf("%s cannot be negative: %d", key, val)
+		}
+	}
+	return
+}
+
+func parseDurationProperty(properties map[string]string, key string) (val time.Duration, err error) {
+	if v, ok := getProperty(properties, key); ok && v != "" {
+		if val, err = time.ParseDuration(v); err != nil {
+			err = fmt.Errorf("failed to parse %s: %v", key, err)
+		} else if val < 0 {
+			err = fmt.Errorf("%s cannot be negative: %s", key, val)
+		}
+	}
+	return
+}
+
+// apply sets the options to the underlying sql.DB
+func (o poolOptions) apply(db *gorm.DB) (err error) {
+	sqlDB, err := db.DB()
+	if err != nil {
+		return
+	}
+
+	if o.MaxOpenConns > 0 {
+		sqlDB.SetMaxOpenConns(o.MaxOpenConns)
+	}
+	if o.MaxIdleConns > 0 {
+		sqlDB.SetMaxIdleConns(o.MaxIdleConns)
+	}
+	if o.ConnMaxLifetime > 0 {
+		sqlDB.SetConnMaxLifetime(o.ConnMaxLifetime)
+	}
+	if o.ConnMaxIdleTime > 0 {
+		sqlDB.SetConnMaxIdleTime(o.ConnMaxIdleTime)
+	}
+	return
+}
+
+func (o poolOptions) String() string {
+	return fmt.Sprintf("%s=%d, %s=%d, %s=%s, %s=%s",
+		propMaxOpenConns, o.MaxOpenConns, propMaxIdleConns, o.MaxIdleConns,
+		propConnMaxLifetime, o.ConnMaxLifetime, propConnMaxIdleTime, o.ConnMaxIdleTime)
+}
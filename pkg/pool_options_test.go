@@ -0,0 +1,96 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParsePoolOptions(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties map[string]string
+		expect     poolOptions
+		hasErr     bool
+	}{{
+		name:       "empty",
+		properties: nil,
+	}, {
+		name: "all options",
+		properties: map[string]string{
+			propMaxOpenConns:    "10",
+			propMaxIdleConns:    "5",
+			propConnMaxLifetime: "1h",
+			propConnMaxIdleTime: "30s",
+		},
+		expect: poolOptions{
+			MaxOpenConns:    10,
+			MaxIdleConns:    5,
+			ConnMaxLifetime: time.Hour,
+			ConnMaxIdleTime: 30 * time.Second,
+		},
+	}, {
+		name:       "invalid number",
+		properties: map[string]string{propMaxOpenConns: "abc"},
+		hasErr:     true,
+	}, {
+		name:       "negative number",
+		properties: map[string]string{propMaxIdleConns: "-1"},
+		hasErr:     true,
+	}, {
+		name:       "invalid duration",
+		properties: map[string]string{propConnMaxLifetime: "10"},
+		hasErr:     true,
+	}, {
+		name:       "negative duration",
+		properties: map[string]string{propConnMaxIdleTime: "-1s"},
+		hasErr:     true,
+	}, {
+		name: "more idle than open",
+		properties: map[string]string{
+			propMaxOpenConns: "2",
+			propMaxIdleConns: "3",
+		},
+		hasErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parsePoolOptions(tt.properties)
+			if tt.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, opts)
+			}
+		})
+	}
+}
+
+func TestPoolOptionsApply(t *testing.T) {
+	db, err := openMemoryDB()
+	assert.NoError(t, err)
+
+	err = poolOptions{MaxOpenConns: 3, MaxIdleConns: 1}.apply(db)
+	assert.NoError(t, err)
+
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, sqlDB.Stats().MaxOpenConnections)
+	assert.NoError(t, sqlDB.Close())
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const propTimeout = "timeout"
+
+// queryCancelTimeout is the timeout of the server-side cancel
+const queryCancelTimeout = 5 * time.Second
+
+// queryTimeout returns the timeout of the query, the hint in the SQL, e.g. /* timeout=5s */, overrides the store property.
+// Zero means no timeout other than the deadline of the request.
+func queryTimeout(properties map[string]string, sqlText string) (timeout time.Duration, err error) {
+	if hint, ok := queryHints(sqlText)[propTimeout]; ok {
+		if timeout, err = time.ParseDuration(hint); err != nil {
+			err = fmt.Errorf("failed to parse the %s hint: %v", propTimeout, err)
+		} else if timeout < 0 {
+			err = fmt.Errorf("the %s hint cannot be negative: %s", propTimeout, timeout)
+		}
+		return
+	}
+	timeout, err = parseDurationProperty(properties, propTimeout)
+	return
+}
+
+// withQueryTimeout applies the timeout of the query to the context
+func withQueryTimeout(ctx context.Context, properties map[string]string, sqlText string) (
+	timeoutCtx context.Context, cancel context.CancelFunc, err error) {
+	var timeout time.Duration
+	if timeout, err = queryTimeout(properties, sqlText); err != nil {
+		return
+	}
+	if timeout > 0 {
+		timeoutCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		timeoutCtx, cancel = context.WithCancel(ctx)
+	}
+	return
+}
+
+// serverCancel represents the statements to cancel the running query of a connection
+type serverCancel struct {
+	connID string
+	// cancel is the format of the statement, which takes the connection ID
+	cancel string
+}
+
+// serverCancels are the drivers which keep running the query after the client gives up.
+// GreptimeDB talks the same protocols, but it does not support the statements.
+var serverCancels = map[string]serverCancel{
+	DialectorMySQL:    {connID: "SELECT CONNECTION_ID()", cancel: "KILL QUERY %d"},
+	DialectorPostgres: {connID: "SELECT pg_backend_pid()", cancel: "SELECT pg_cancel_backend(%d)"},
+}
+
+type serverCancelKey struct{}
+
+// withServerCancelDriver enables the server-side cancel of the driver for the queries of the context.
+// It is only for the user queries, the internal metadata queries are cancelled by the driver.
+func withServerCancelDriver(ctx context.Context, driver string) context.Context {
+	if canceler, ok := serverCancels[driver]; ok {
+		ctx = context.WithValue(ctx, serverCancelKey{}, canceler)
+	}
+	return ctx
+}
+
+// withServerCancel runs the query on a dedicated connection, and cancels it on the server side
+// once the context is done. The queries routed by the replicas are only cancelled by the driver,
+// so are the ones of a pool with a single connection, which has no connection left for the cancel.
+func withServerCancel(ctx context.Context, db *gorm.DB, query func(tx *gorm.DB) error) (err error) {
+	canceler, ok := ctx.Value(serverCancelKey{}).(serverCancel)
+	if !ok || ctx.Done() == nil || hasReplicas(db) || isSingleConnPool(db) {
+		return query(db.WithContext(ctx))
+	}
+
+	err = db.WithContext(ctx).Connection(func(tx *gorm.DB) (err error) {
+		var connID int64
+		if idErr := tx.Session(&gorm.Session{}).Raw(canceler.connID).Scan(&connID).Error; idErr != nil {
+			log.Printf("failed to get the connection ID, the query is only cancelled by the driver: %v", idErr)
+			return query(tx)
+		}
+
+		cancelled := make(chan struct{})
+		stop := context.AfterFunc(ctx, func() {
+			defer close(cancelled)
+			cancelCtx, cancel := context.WithTimeout(context.Background(), queryCancelTimeout)
+			defer cancel()
+			if cancelErr := db.Session(&gorm.Session{NewDB: true}).WithContext(cancelCtx).
+				Exec(fmt.Sprintf(canceler.cancel, connID)).Error; cancelErr != nil {
+				log.Printf("failed to cancel the query of connection %d: %v", connID, cancelErr)
+			}
+		})
+		defer func() {
+			// the connection must not go back to the pool before the cancel finished
+			if !stop() {
+				<-cancelled
+			}
+		}()
+		err = query(tx)
+		return
+	})
+	return
+}
+
+// isSingleConnPool reports whether the pool is limited to one connection
+func isSingleConnPool(db *gorm.DB) bool {
+	sqlDB, err := db.DB()
+	return err == nil && sqlDB.Stats().MaxOpenConnections == 1
+}
@@ -0,0 +1,248 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	gosqlite "github.com/glebarez/go-sqlite"
+	"github.com/linuxsuren/api-testing/pkg/server"
+	atest "github.com/linuxsuren/api-testing/pkg/testing"
+	"github.com/linuxsuren/api-testing/pkg/testing/remote"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func init() {
+	// slow(ms) sleeps before returning, which makes a query run as long as needed
+	gosqlite.MustRegisterScalarFunction("slow", 1, func(_ *gosqlite.FunctionContext, args []driver.Value) (driver.Value, error) {
+		time.Sleep(time.Duration(args[0].(int64)) * time.Millisecond)
+		return args[0], nil
+	})
+}
+
+func TestQueryTimeout(t *testing.T) {
+	tests := []struct {
+		name       string
+		properties map[string]string
+		sql        string
+		expect     time.Duration
+		hasErr     bool
+	}{{
+		name: "no timeout",
+		sql:  "SELECT 1",
+	}, {
+		name:       "store property",
+		properties: map[string]string{"Timeout": "3s"},
+		sql:        "SELECT 1",
+		expect:     3 * time.Second,
+	}, {
+		name:       "hint overrides the property",
+		properties: map[string]string{propTimeout: "3s"},
+		sql:        "SELECT /* timeout=500ms */ 1",
+		expect:     500 * time.Millisecond,
+	}, {
+		name:   "optimizer hint style",
+		sql:    "/*+ TIMEOUT = 1m */ SELECT 1",
+		expect: time.Minute,
+	}, {
+		name:   "invalid hint",
+		sql:    "SELECT /* timeout=fake */ 1",
+		hasErr: true,
+	}, {
+		name:       "invalid property",
+		properties: map[string]string{propTimeout: "-1s"},
+		sql:        "SELECT 1",
+		hasErr:     true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			timeout, err := queryTimeout(tt.properties, tt.sql)
+			if tt.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, timeout)
+			}
+		})
+	}
+}
+
+func TestQueryCancellation(t *testing.T) {
+	remoteServer := NewRemoteServer(10, "")
+	newContext := func(properties map[string]string) context.Context {
+		properties["driver"] = driverSQLitePureGo
+		properties["database"] = "query_cancellation"
+		return remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
+			Name:       t.Name(),
+			URL:        SQLiteMemory,
+			Properties: properties,
+		})
+	}
+	const slowSQL = "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c LIMIT 1000) SELECT slow(10) FROM c"
+
+	t.Run("store property", func(t *testing.T) {
+		start := time.Now()
+		_, err := remoteServer.Query(newContext(map[string]string{propTimeout: "200ms"}), &server.DataQuery{Sql: slowSQL})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("hint", func(t *testing.T) {
+		start := time.Now()
+		_, err := remoteServer.Query(newContext(map[string]string{propTimeout: "1m"}),
+			&server.DataQuery{Sql: "/* timeout=200ms */ " + slowSQL})
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("request cancelled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(newContext(map[string]string{}))
+		time.AfterFunc(200*time.Millisecond, cancel)
+		start := time.Now()
+		_, err := remoteServer.Query(ctx, &server.DataQuery{Sql: slowSQL})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Less(t, time.Since(start), 5*time.Second)
+	})
+
+	t.Run("fast query", func(t *testing.T) {
+		result, err := remoteServer.Query(newContext(map[string]string{propTimeout: "5s"}),
+			&server.DataQuery{Sql: "SELECT slow(1) AS x"})
+		assert.NoError(t, err)
+		assert.Equal(t, []*server.Pair{{Key: "x", Value: "1"}}, result.Items[0].Data)
+	})
+}
+
+func TestServerCancel(t *testing.T) {
+	tests := []struct {
+		name      string
+		dialector func(conn gorm.ConnPool) gorm.Dialector
+		connID    string
+		cancel    string
+	}{{
+		name: "mysql",
+		dialector: func(conn gorm.ConnPool) gorm.Dialector {
+			return mysql.New(mysql.Config{Conn: conn, SkipInitializeWithVersion: true})
+		},
+		connID: "SELECT CONNECTION_ID()",
+		cancel: "KILL QUERY 42",
+	}, {
+		name: "postgres",
+		dialector: func(conn gorm.ConnPool) gorm.Dialector {
+			return postgres.New(postgres.Config{Conn: conn})
+		},
+		connID: "SELECT pg_backend_pid()",
+		cancel: "SELECT pg_cancel_backend(42)",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+			assert.NoError(t, err)
+			defer sqlDB.Close()
+			mock.MatchExpectationsInOrder(false)
+
+			db, err := gorm.Open(tt.dialector(sqlDB), &gorm.Config{})
+			assert.NoError(t, err)
+
+			mock.ExpectQuery(tt.connID).WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(42))
+			mock.ExpectQuery("SELECT slow()").WillDelayFor(time.Minute).
+				WillReturnRows(sqlmock.NewRows([]string{"x"}).AddRow(1))
+			mock.ExpectExec(tt.cancel).WillReturnResult(sqlmock.NewResult(0, 0))
+
+			ctx, cancel := context.WithTimeout(context.TODO(), 200*time.Millisecond)
+			defer cancel()
+			_, err = sqlQuery(withServerCancelDriver(ctx, tt.name), "SELECT slow()", db)
+			assert.Error(t, err)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+
+	newMySQL := func(t *testing.T) (*gorm.DB, sqlmock.Sqlmock) {
+		sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		assert.NoError(t, err)
+		t.Cleanup(func() { sqlDB.Close() })
+
+		db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+		assert.NoError(t, err)
+		return db, mock
+	}
+
+	t.Run("failed to get the connection ID", func(t *testing.T) {
+		db, mock := newMySQL(t)
+		mock.ExpectQuery("SELECT CONNECTION_ID()").WillReturnError(errors.New("not supported"))
+		mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"x"}).AddRow(1))
+
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Minute)
+		defer cancel()
+		result, err := sqlQuery(withServerCancelDriver(ctx, DialectorMySQL), "SELECT 1", db)
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("metadata and GreptimeDB queries", func(t *testing.T) {
+		db, mock := newMySQL(t)
+		mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"x"}).AddRow(1))
+		mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"x"}).AddRow(1))
+
+		ctx, cancel := context.WithTimeout(context.TODO(), time.Minute)
+		defer cancel()
+		_, err := sqlQuery(ctx, "SELECT 1", db)
+		assert.NoError(t, err)
+		_, err = sqlQuery(withServerCancelDriver(ctx, DialectorGreptime), "SELECT 1", db)
+		assert.NoError(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("single connection pool", func(t *testing.T) {
+		db, mock := newMySQL(t)
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+		sqlDB.SetMaxOpenConns(1)
+		mock.ExpectQuery("SELECT slow()").WillDelayFor(time.Minute).
+			WillReturnRows(sqlmock.NewRows([]string{"x"}).AddRow(1))
+
+		ctx, cancel := context.WithTimeout(context.TODO(), 200*time.Millisecond)
+		defer cancel()
+		start := time.Now()
+		_, err = sqlQuery(withServerCancelDriver(ctx, DialectorMySQL), "SELECT slow()", db)
+		assert.Error(t, err)
+		assert.Less(t, time.Since(start), queryCancelTimeout)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("without a deadline", func(t *testing.T) {
+		sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+		assert.NoError(t, err)
+		defer sqlDB.Close()
+
+		db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+		assert.NoError(t, err)
+
+		mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"x"}).AddRow(1))
+		result, err := sqlQuery(withServerCancelDriver(context.Background(), DialectorMySQL), "SELECT 1", db)
+		assert.NoError(t, err)
+		assert.Len(t, result.Items, 1)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+// propReadOnly rejects the writes of the data query, and opens the embedded databases in read-only mode
+const propReadOnly = "readOnly"
+
+var (
+	// readStatements are the first keywords of the statements which only read
+	readStatements = []string{"SELECT", "WITH", "VALUES", "TABLE", "EXPLAIN", "SHOW", "DESCRIBE", "DESC", "PRAGMA", "TQL"}
+	// metadataStatements never write, so their words are not checked, e.g. SHOW CREATE TABLE
+	metadataStatements = []string{"SHOW", "DESCRIBE", "DESC", "TQL"}
+	// writeWords make a read statement write, e.g. a data-modifying CTE, SELECT INTO,
+	// SELECT FOR UPDATE or EXPLAIN ANALYZE DELETE
+	writeWords = []string{"INSERT", "UPDATE", "DELETE", "MERGE", "UPSERT", "REPLACE", "CREATE", "DROP", "ALTER",
+		"TRUNCATE", "GRANT", "REVOKE", "RENAME", "INTO", "CALL", "EXEC", "EXECUTE", "COPY", "LOCK", "ATTACH",
+		"DETACH", "VACUUM", "OPTIMIZE", "KILL", "LOAD"}
+	// queryPragmas of SQLite only read, even with an argument, e.g. PRAGMA table_info(users)
+	queryPragmas = []string{"TABLE_INFO", "TABLE_XINFO", "TABLE_LIST", "INDEX_LIST", "INDEX_INFO", "INDEX_XINFO",
+		"FOREIGN_KEY_LIST", "FOREIGN_KEY_CHECK", "INTEGRITY_CHECK", "QUICK_CHECK", "DATABASE_LIST",
+		"COLLATION_LIST", "FUNCTION_LIST", "MODULE_LIST", "PRAGMA_LIST", "COMPILE_OPTIONS", "DATA_VERSION",
+		"FREELIST_COUNT", "PAGE_COUNT"}
+	// getterPragmas of SQLite only read without an argument, e.g. PRAGMA journal_mode,
+	// but set the value with it, e.g. PRAGMA journal_mode(WAL) or PRAGMA user_version = 5
+	getterPragmas = []string{"APPLICATION_ID", "AUTO_VACUUM", "BUSY_TIMEOUT", "CACHE_SIZE", "CACHE_SPILL",
+		"CASE_SENSITIVE_LIKE", "ENCODING", "FOREIGN_KEYS", "JOURNAL_MODE", "JOURNAL_SIZE_LIMIT", "LOCKING_MODE",
+		"MAX_PAGE_COUNT", "MMAP_SIZE", "PAGE_SIZE", "QUERY_ONLY", "RECURSIVE_TRIGGERS", "SCHEMA_VERSION",
+		"SECURE_DELETE", "SYNCHRONOUS", "TEMP_STORE", "USER_VERSION", "WAL_AUTOCHECKPOINT"}
+	// writeFunctions have side effects even in a read-only transaction, e.g. SELECT pg_terminate_backend(1),
+	// or write on the dialects without it, e.g. SELECT setval('s', 1)
+	writeFunctions = []string{"PG_TERMINATE_BACKEND", "PG_CANCEL_BACKEND", "PG_RELOAD_CONF", "PG_ROTATE_LOGFILE",
+		"PG_SWITCH_WAL", "PG_CREATE_RESTORE_POINT", "PG_PROMOTE", "PG_ADVISORY_LOCK", "PG_ADVISORY_XACT_LOCK",
+		"PG_CREATE_LOGICAL_REPLICATION_SLOT", "PG_CREATE_PHYSICAL_REPLICATION_SLOT", "PG_DROP_REPLICATION_SLOT",
+		"PG_LOGICAL_EMIT_MESSAGE", "SET_CONFIG", "SETVAL", "NEXTVAL", "LO_IMPORT", "LO_EXPORT", "LO_UNLINK",
+		"LO_CREATE", "DBLINK_EXEC", "GET_LOCK", "RELEASE_LOCK", "RELEASE_ALL_LOCKS", "LOAD_EXTENSION"}
+	// readOnlyTxDialects support the read-only transactions
+	readOnlyTxDialects = []string{DialectorMySQL, DialectorPostgres}
+)
+
+// classifyStatement returns the first keyword of the statement, and whether it only reads
+func classifyStatement(statement, dialect string) (keyword string, read bool) {
+	words, calls := statementWords(statement, dialect)
+	if len(words) == 0 {
+		return
+	}
+	keyword = words[0]
+	switch {
+	case !slices.Contains(readStatements, keyword):
+		return
+	case slices.Contains(metadataStatements, keyword):
+		read = true
+		return
+	case keyword == "PRAGMA":
+		read = isQueryPragma(statement, words, calls)
+		return
+	}
+	for i, word := range words {
+		// the function calls are not the keywords, e.g. SELECT REPLACE(name, 'a', 'b')
+		if !calls[i] && slices.Contains(writeWords, word) || calls[i] && slices.Contains(writeFunctions, word) {
+			keyword = word
+			return
+		}
+	}
+	read = true
+	return
+}
+
+// isQueryPragma returns true if the pragma only reads, the schema name is optional, e.g. PRAGMA main.table_info(users)
+func isQueryPragma(statement string, words []string, calls []bool) bool {
+	index := 1
+	if len(words) > 2 && !slices.Contains(queryPragmas, words[1]) && !slices.Contains(getterPragmas, words[1]) {
+		index = 2
+	}
+	if index >= len(words) {
+		return false
+	}
+	if name := words[index]; slices.Contains(queryPragmas, name) {
+		return true
+	} else if slices.Contains(getterPragmas, name) {
+		return len(words) == index+1 && !calls[index] && !strings.Contains(statement, "=")
+	}
+	return false
+}
+
+// statementWords returns the upper case words outside the quotes and comments,
+// calls reports whether the word is followed by a parenthesis
+func statementWords(statement, dialect string) (words []string, calls []bool) {
+	opts := splitOptionsOf(dialect)
+	for i := 0; i < len(statement); {
+		end := skippedEnd(statement, i, opts)
+		if rest := statement[i:]; end == 0 {
+			end = 1
+			if isIdentifierChar(rest, 0) && !isIdentifierChar(statement, i-1) {
+				for end < len(rest) && isIdentifierChar(rest, end) {
+					end++
+				}
+				words = append(words, strings.ToUpper(rest[:end]))
+				calls = append(calls, strings.HasPrefix(strings.TrimSpace(rest[end:]), "("))
+			}
+		}
+		i += end
+	}
+	return
+}
+
+// checkReadOnly rejects the statements which might write
+func checkReadOnly(statements []string, dialect string) (err error) {
+	for i, statement := range statements {
+		if keyword, read := classifyStatement(statement, dialect); !read {
+			err = fmt.Errorf("the %s statement %d is not allowed in the read-only mode: %s", keyword, i+1, statement)
+			return
+		}
+	}
+	return
+}
+
+type readOnlyKey struct{}
+
+// withReadOnlyProperty marks the context read-only when the store property readOnly is true
+func withReadOnlyProperty(ctx context.Context, properties map[string]string) (context.Context, error) {
+	readOnly, err := parseBoolProperty(properties, propReadOnly)
+	if readOnly {
+		ctx = withReadOnly(ctx)
+	}
+	return ctx, err
+}
+
+func withReadOnly(ctx context.Context) context.Context {
+	return context.WithValue(ctx, readOnlyKey{}, true)
+}
+
+func isReadOnly(ctx context.Context) bool {
+	readOnly, _ := ctx.Value(readOnlyKey{}).(bool)
+	return readOnly
+}
+
+// withReadOnlyTx runs the query in a read-only transaction when the context is read-only and
+// the dialect supports it. The transaction goes to the replicas if there are any.
+func withReadOnlyTx(ctx context.Context, db *gorm.DB, query func(tx *gorm.DB) error) error {
+	if !isReadOnly(ctx) || !slices.Contains(readOnlyTxDialects, db.Dialector.Name()) {
+		return query(db)
+	}
+	if hasReplicas(db) {
+		db = db.Clauses(dbresolver.Read)
+	}
+	return db.Transaction(query, &sql.TxOptions{ReadOnly: true})
+}
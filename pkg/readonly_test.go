@@ -0,0 +1,151 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/linuxsuren/api-testing/pkg/server"
+	atest "github.com/linuxsuren/api-testing/pkg/testing"
+	"github.com/linuxsuren/api-testing/pkg/testing/remote"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+)
+
+func TestClassifyStatement(t *testing.T) {
+	tests := []struct {
+		statement string
+		dialect   string
+		keyword   string
+		read      bool
+	}{
+		{statement: "SELECT * FROM users", keyword: "SELECT", read: true},
+		{statement: "/* hint */ select replace(name, 'a', 'b') from users", keyword: "SELECT", read: true},
+		{statement: "(SELECT 1) UNION (SELECT 2)", keyword: "SELECT", read: true},
+		{statement: "SELECT 'DELETE FROM users', \"update\" FROM t -- drop", keyword: "SELECT", read: true},
+		{statement: "WITH t AS (SELECT 1) SELECT * FROM t", keyword: "WITH", read: true},
+		{statement: "SHOW CREATE TABLE users", dialect: DialectorMySQL, keyword: "SHOW", read: true},
+		{statement: "DESC users", keyword: "DESC", read: true},
+		{statement: "EXPLAIN SELECT * FROM users", keyword: "EXPLAIN", read: true},
+		{statement: "PRAGMA table_info(users)", keyword: "PRAGMA", read: true},
+		{statement: "TQL EVAL (0, 10, '5s') up", keyword: "TQL", read: true},
+		{statement: "SELECT $$DELETE$$", dialect: DialectorPostgres, keyword: "SELECT", read: true},
+		{statement: "DELETE FROM users", keyword: "DELETE"},
+		{statement: "drop table users", keyword: "DROP"},
+		{statement: "INSERT INTO users VALUES (1)", keyword: "INSERT"},
+		{statement: "UPDATE users SET name = 'a'", keyword: "UPDATE"},
+		{statement: "SET NAMES utf8", keyword: "SET"},
+		{statement: "WITH d AS (DELETE FROM users RETURNING *) SELECT * FROM d", dialect: DialectorPostgres, keyword: "DELETE"},
+		{statement: "SELECT * INTO backup FROM users", keyword: "INTO"},
+		{statement: "SELECT * FROM users FOR UPDATE", keyword: "UPDATE"},
+		{statement: "EXPLAIN ANALYZE DELETE FROM users", keyword: "DELETE"},
+		{statement: "PRAGMA journal_mode=WAL", keyword: "PRAGMA"},
+		{statement: "PRAGMA journal_mode(WAL)", keyword: "PRAGMA"},
+		{statement: "PRAGMA main.user_version(5)", keyword: "PRAGMA"},
+		{statement: "PRAGMA user_version = '5'", keyword: "PRAGMA"},
+		{statement: "PRAGMA journal_mode", keyword: "PRAGMA", read: true},
+		{statement: "PRAGMA main.table_info('users')", keyword: "PRAGMA", read: true},
+		{statement: "PRAGMA optimize", keyword: "PRAGMA"},
+		{statement: "PRAGMA", keyword: "PRAGMA"},
+		{statement: "SELECT pg_terminate_backend(1)", dialect: DialectorPostgres, keyword: "PG_TERMINATE_BACKEND"},
+		{statement: "select setval('s', 1)", dialect: DialectorPostgres, keyword: "SETVAL"},
+		{statement: "SELECT GET_LOCK('a', 10)", dialect: DialectorMySQL, keyword: "GET_LOCK"},
+		{statement: "SELECT 'setval(1)', nextval FROM t", dialect: DialectorPostgres, keyword: "SELECT", read: true},
+		{statement: "-- only a comment", keyword: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.statement, func(t *testing.T) {
+			keyword, read := classifyStatement(tt.statement, tt.dialect)
+			assert.Equal(t, tt.keyword, keyword)
+			assert.Equal(t, tt.read, read)
+		})
+	}
+}
+
+func TestReadOnlyQuery(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "readonly.db")
+	remoteServer := NewRemoteServer(10, "")
+	newContext := func(readOnly string) context.Context {
+		return remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
+			Name: t.Name() + readOnly,
+			URL:  path,
+			Properties: map[string]string{
+				"driver":     driverSQLitePureGo,
+				propReadOnly: readOnly,
+			},
+		})
+	}
+
+	_, err := remoteServer.Query(newContext("false"), &server.DataQuery{
+		Sql: "CREATE TABLE users (name TEXT); INSERT INTO users VALUES ('alice')",
+	})
+	assert.NoError(t, err)
+
+	result, err := remoteServer.Query(newContext("true"), &server.DataQuery{Sql: "SELECT name FROM users"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*server.Pair{{Key: "name", Value: "alice"}}, result.Items[0].Data)
+
+	_, err = remoteServer.Query(newContext("true"), &server.DataQuery{Sql: "SELECT 1; DELETE FROM users"})
+	assert.ErrorContains(t, err, "the DELETE statement 2 is not allowed in the read-only mode")
+	_, err = remoteServer.Query(newContext("true"), &server.DataQuery{Sql: "PRAGMA user_version(5)"})
+	assert.ErrorContains(t, err, "the PRAGMA statement 1 is not allowed in the read-only mode")
+	result, err = remoteServer.Query(newContext("true"), &server.DataQuery{Sql: "PRAGMA query_only"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*server.Pair{{Key: "query_only", Value: "1"}}, result.Items[0].Data)
+
+	_, err = remoteServer.(QueryStreamer).StreamQuery(newContext("true"), &server.DataQuery{Sql: "DROP TABLE users"},
+		func(*server.Pairs) error { return nil })
+	assert.ErrorContains(t, err, "the DROP statement 1 is not allowed in the read-only mode")
+
+	mcpStore := &atest.Store{Name: t.Name() + "mcp", URL: path, Properties: map[string]string{
+		"driver":     driverSQLitePureGo,
+		propReadOnly: "true",
+	}}
+	_, _, err = NewMcpServer(mcpStore).Query(context.TODO(), nil, DBQuery{SQL: "UPDATE users SET name = 'bob'"})
+	assert.ErrorContains(t, err, "the UPDATE statement 1 is not allowed in the read-only mode")
+
+	result, err = remoteServer.Query(newContext("false"), &server.DataQuery{Sql: "SELECT name FROM users"})
+	assert.NoError(t, err)
+	assert.Equal(t, []*server.Pair{{Key: "name", Value: "alice"}}, result.Items[0].Data)
+
+	_, err = remoteServer.Query(newContext("fake"), &server.DataQuery{Sql: "SELECT 1"})
+	assert.Error(t, err)
+}
+
+func TestReadOnlyTx(t *testing.T) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	defer sqlDB.Close()
+	db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+	assert.NoError(t, err)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"x"}).AddRow(1))
+	mock.ExpectCommit()
+	result, err := sqlQuery(withReadOnly(context.Background()), "SELECT 1", db)
+	assert.NoError(t, err)
+	assert.Len(t, result.Items, 1)
+
+	// no transaction without the read-only mode
+	mock.ExpectQuery("SELECT 1").WillReturnRows(sqlmock.NewRows([]string{"x"}).AddRow(1))
+	_, err = sqlQuery(context.Background(), "SELECT 1", db)
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/logger"
+)
+
+const redactedText = "******"
+
+// the password of the PostgreSQL keyword/value connection string
+var keywordPasswordPattern = regexp.MustCompile(`(?i)(password\s*=\s*)('(?:[^'\\]|\\.)*'|\S+)`)
+
+// redactDSN hides the password of a DSN in the formats of MySQL, TDengine,
+// PostgreSQL keyword/value and URL.
+func redactDSN(dsn string) string {
+	if strings.Contains(dsn, "://") {
+		if u, err := url.Parse(dsn); err == nil && u.User != nil {
+			if _, ok := u.User.Password(); ok {
+				u.User = url.UserPassword(u.User.Username(), "xxxxx")
+				return strings.Replace(u.String(), "xxxxx", redactedText, 1)
+			}
+			return dsn
+		}
+	}
+
+	dsn = keywordPasswordPattern.ReplaceAllString(dsn, "${1}"+redactedText)
+
+	// user:password@protocol(address)/database
+	if at := strings.LastIndex(dsn, "@"); at > 0 {
+		if colon := strings.Index(dsn[:at], ":"); colon >= 0 && !strings.ContainsAny(dsn[:colon], " =/") {
+			dsn = dsn[:colon+1] + redactedText + dsn[at:]
+		}
+	}
+	return dsn
+}
+
+// redactSecrets replaces all the secrets in the text
+func redactSecrets(text string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.ReplaceAll(text, secret, redactedText)
+		}
+	}
+	return text
+}
+
+// redactWriter removes the secrets from the gorm logs
+type redactWriter struct {
+	logger.Writer
+	secrets []string
+}
+
+func (w redactWriter) Printf(format string, args ...interface{}) {
+	w.Writer.Printf("%s", redactSecrets(fmt.Sprintf(format, args...), w.secrets...))
+}
+
+// newRedactLogger creates a gorm logger same as the default one but without the secrets. The logs go to
+// stderr like the standard logger, which keeps the output of the commands clean, e.g. the rows of query.
+func newRedactLogger(secrets ...string) logger.Interface {
+	return logger.New(redactWriter{
+		Writer:  log.New(os.Stderr, "\r\n", log.LstdFlags),
+		secrets: secrets,
+	}, logger.Config{
+		SlowThreshold: 200 * time.Millisecond,
+		LogLevel:      logger.Info,
+		Colorful:      true,
+	})
+}
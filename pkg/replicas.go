@@ -0,0 +1,273 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+const propReplicas = "replicas"
+
+// replicaCheckInterval is the interval to check the health of a replica
+const replicaCheckInterval = 10 * time.Second
+
+func parseReplicas(properties map[string]string) (addresses []string) {
+	if v, ok := getProperty(properties, propReplicas); ok {
+		for _, address := range strings.Split(v, ",") {
+			if address = strings.TrimSpace(address); address != "" {
+				addresses = append(addresses, address)
+			}
+		}
+	}
+	return
+}
+
+// useReplicas routes the read queries to the replicas, which share the credentials,
+// database and properties of the primary except the property dsn.
+// The writes and transactions always go to the primary.
+func useReplicas(db *gorm.DB, user, password, database, driver string, tlsOpts tlsOptions,
+	pool poolOptions, properties map[string]string, secrets ...string) (err error) {
+	addresses := parseReplicas(properties)
+	if len(addresses) == 0 {
+		return
+	}
+
+	switch driver {
+	case "sqlite", driverSQLitePureGo, DialectorDuckDB:
+		err = fmt.Errorf("the embedded database %s does not support replicas", driver)
+		return
+	}
+
+	replicaProperties := make(map[string]string, len(properties))
+	for key, val := range properties {
+		if !strings.EqualFold(key, propDSN) {
+			replicaProperties[key] = val
+		}
+	}
+
+	dialectors := make([]gorm.Dialector, 0, len(addresses))
+	for _, address := range addresses {
+		var dsn string
+		if dsn, err = buildDSN(user, password, address, database, driver, tlsOpts, replicaProperties); err != nil {
+			return
+		}
+		var dialector gorm.Dialector
+		if dialector, err = newDialector(driver, dsn, tlsOpts, replicaProperties); err != nil {
+			return
+		}
+
+		log.Printf("add the replica %q", redactSecrets(redactDSN(dsn), secrets...))
+		dialectors = append(dialectors, &replicaDialector{
+			Dialector: dialector,
+			address:   address,
+			primary:   db.ConnPool,
+			pool:      pool,
+		})
+	}
+
+	err = db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: dialectors,
+		Policy:   &healthyReplicaPolicy{},
+	}))
+	return
+}
+
+func replicaResolver(db *gorm.DB) (resolver *dbresolver.DBResolver, ok bool) {
+	resolver, ok = db.Config.Plugins[(&dbresolver.DBResolver{}).Name()].(*dbresolver.DBResolver)
+	return
+}
+
+// hasReplicas reports whether the reads are routed to the replicas
+func hasReplicas(db *gorm.DB) bool {
+	_, ok := replicaResolver(db)
+	return ok
+}
+
+// primaryDB routes the reads to the primary as well, e.g. the reads followed by the writes,
+// which must not see the stale data of the replicas
+func primaryDB(db *gorm.DB) *gorm.DB {
+	if !hasReplicas(db) {
+		return db
+	}
+	return db.Clauses(dbresolver.Write).Session(&gorm.Session{})
+}
+
+// closeReplicas closes the connections of the replicas
+func closeReplicas(db *gorm.DB) {
+	resolver, ok := replicaResolver(db)
+	if !ok {
+		return
+	}
+
+	_ = resolver.Call(func(connPool gorm.ConnPool) error {
+		if replica, ok := connPool.(*replicaConnPool); ok {
+			if err := replica.Close(); err != nil {
+				log.Printf("failed to close the replica %q: %v", replica.address, err)
+			}
+		}
+		return nil
+	})
+}
+
+// replicaDialector opens the replica, and tolerates the unavailable replica when connecting
+type replicaDialector struct {
+	gorm.Dialector
+	address string
+	primary gorm.ConnPool
+	pool    poolOptions
+}
+
+func (d *replicaDialector) Initialize(db *gorm.DB) (err error) {
+	replica := &replicaConnPool{
+		address: d.address,
+		primary: d.primary,
+	}
+	replica.healthy.Store(true)
+	if err = d.Dialector.Initialize(db); err != nil {
+		if db.ConnPool == nil {
+			return
+		}
+		// the queries go to the primary until the replica is back
+		log.Printf("the replica %q is unavailable: %v", d.address, err)
+		replica.healthy.Store(false)
+		replica.checkedAt.Store(time.Now().UnixNano())
+	}
+	if err = d.pool.apply(db); err != nil {
+		return
+	}
+
+	replica.ConnPool = db.ConnPool
+	db.ConnPool = replica
+	return
+}
+
+// replicaConnPool sends the statements to the replica,
+// or the primary when the replica is unhealthy
+type replicaConnPool struct {
+	gorm.ConnPool
+	address string
+	primary gorm.ConnPool
+
+	healthy atomic.Bool
+	// checkedAt is the time of the last check in nanoseconds
+	checkedAt atomic.Int64
+}
+
+func (p *replicaConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.target(ctx).PrepareContext(ctx, query)
+}
+
+func (p *replicaConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	return p.target(ctx).ExecContext(ctx, query, args...)
+}
+
+func (p *replicaConnPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return p.target(ctx).QueryContext(ctx, query, args...)
+}
+
+func (p *replicaConnPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return p.target(ctx).QueryRowContext(ctx, query, args...)
+}
+
+// BeginTx starts the transaction on the replica, e.g. the read-only one of a data query
+func (p *replicaConnPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	switch beginner := p.target(ctx).(type) {
+	case gorm.TxBeginner:
+		return beginner.BeginTx(ctx, opts)
+	case gorm.ConnPoolBeginner:
+		return beginner.BeginTx(ctx, opts)
+	}
+	return nil, gorm.ErrInvalidTransaction
+}
+
+func (p *replicaConnPool) Close() (err error) {
+	if connector, ok := p.ConnPool.(gorm.GetDBConnector); ok {
+		var sqlDB *sql.DB
+		if sqlDB, err = connector.GetDBConn(); err == nil {
+			err = sqlDB.Close()
+		}
+	} else if sqlDB, ok := p.ConnPool.(*sql.DB); ok {
+		err = sqlDB.Close()
+	}
+	return
+}
+
+func (p *replicaConnPool) target(ctx context.Context) gorm.ConnPool {
+	if p.isHealthy(ctx) {
+		return p.ConnPool
+	}
+	return p.primary
+}
+
+// isHealthy pings the replica once in the check interval. Only the caller which takes the check pings it,
+// the others go on with the result of the last check instead of waiting for the ping.
+func (p *replicaConnPool) isHealthy(ctx context.Context) bool {
+	checkedAt := p.checkedAt.Load()
+	if time.Since(time.Unix(0, checkedAt)) < replicaCheckInterval ||
+		!p.checkedAt.CompareAndSwap(checkedAt, time.Now().UnixNano()) {
+		return p.healthy.Load()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, time.Second*3)
+	defer cancel()
+	err := pingConnPool(ctx, p.ConnPool)
+	healthy := err == nil
+	if healthy != p.healthy.Swap(healthy) {
+		if healthy {
+			log.Printf("the replica %q is recovered", p.address)
+		} else {
+			log.Printf("the replica %q is unhealthy, fall back to the primary: %v", p.address, err)
+		}
+	}
+	p.checkedAt.Store(time.Now().UnixNano())
+	return healthy
+}
+
+func pingConnPool(ctx context.Context, connPool gorm.ConnPool) error {
+	switch pinger := connPool.(type) {
+	case interface{ PingContext(context.Context) error }:
+		return pinger.PingContext(ctx)
+	case interface{ Ping() error }:
+		return pinger.Ping()
+	}
+	return nil
+}
+
+// healthyReplicaPolicy resolves the healthy replicas in turn
+type healthyReplicaPolicy struct {
+	next atomic.Uint64
+}
+
+func (p *healthyReplicaPolicy) Resolve(connPools []gorm.ConnPool) gorm.ConnPool {
+	start := p.next.Add(1)
+	for i := range connPools {
+		connPool := connPools[(start+uint64(i))%uint64(len(connPools))]
+		if replica, ok := connPool.(*replicaConnPool); !ok || replica.isHealthy(context.Background()) {
+			return connPool
+		}
+	}
+	// every replica falls back to the primary
+	return connPools[start%uint64(len(connPools))]
+}
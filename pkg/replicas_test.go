@@ -0,0 +1,208 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/plugin/dbresolver"
+)
+
+func TestParseReplicas(t *testing.T) {
+	assert.Empty(t, parseReplicas(nil))
+	assert.Empty(t, parseReplicas(map[string]string{propReplicas: " , "}))
+	assert.Equal(t, []string{"replica1:3306", "replica2"},
+		parseReplicas(map[string]string{"Replicas": "replica1:3306, replica2,"}))
+}
+
+func TestUseReplicas(t *testing.T) {
+	for _, driver := range []string{"sqlite", DialectorDuckDB} {
+		err := useReplicas(nil, "", "", "atest", driver, tlsOptions{}, poolOptions{},
+			map[string]string{propReplicas: "replica.db"})
+		assert.Error(t, err, driver)
+	}
+	assert.NoError(t, useReplicas(nil, "", "", "atest", DialectorMySQL, tlsOptions{}, poolOptions{}, nil))
+}
+
+func TestReplicaRouting(t *testing.T) {
+	dir := t.TempDir()
+	openStore := func(name string) *gorm.DB {
+		db, err := gorm.Open(openSQLite("sqlite", filepath.Join(dir, name)), &gorm.Config{})
+		assert.NoError(t, err)
+		assert.NoError(t, db.AutoMigrate(&TestSuite{}))
+		assert.NoError(t, db.Create(&TestSuite{Name: name}).Error)
+		return db
+	}
+	openStore("replica.db")
+	db := openStore("primary.db")
+
+	assert.NoError(t, db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{&replicaDialector{
+			Dialector: openSQLite("sqlite", filepath.Join(dir, "replica.db")),
+			address:   "replica.db",
+			primary:   db.ConnPool,
+			pool:      poolOptions{MaxOpenConns: 2},
+		}},
+		Policy: &healthyReplicaPolicy{},
+	})))
+	var replica *replicaConnPool
+	_ = db.Config.Plugins[(&dbresolver.DBResolver{}).Name()].(*dbresolver.DBResolver).Call(func(connPool gorm.ConnPool) error {
+		if pool, ok := connPool.(*replicaConnPool); ok {
+			replica = pool
+		}
+		return nil
+	})
+	if !assert.NotNil(t, replica) {
+		return
+	}
+
+	listSuites := func() (names []string) {
+		assert.NoError(t, db.Model(&TestSuite{}).Order("name").Pluck("name", &names).Error)
+		return
+	}
+
+	t.Run("reads go to the replica", func(t *testing.T) {
+		assert.Equal(t, []string{"replica.db"}, listSuites())
+
+		var name string
+		assert.NoError(t, db.Raw("SELECT name FROM test_suites").Scan(&name).Error)
+		assert.Equal(t, "replica.db", name)
+	})
+
+	t.Run("writes go to the primary", func(t *testing.T) {
+		assert.NoError(t, db.Create(&TestSuite{Name: "created"}).Error)
+		assert.NoError(t, db.Exec("UPDATE test_suites SET api = ? WHERE name = ?", "http://localhost", "created").Error)
+		assert.Equal(t, []string{"replica.db"}, listSuites())
+
+		var suite TestSuite
+		assert.NoError(t, db.Clauses(dbresolver.Write).First(&suite, "name = ?", "created").Error)
+		assert.Equal(t, "http://localhost", suite.API)
+	})
+
+	t.Run("fall back to the primary", func(t *testing.T) {
+		closeReplicas(db)
+		replica.checkedAt.Store(0)
+		assert.Equal(t, []string{"created", "primary.db"}, listSuites())
+		assert.False(t, replica.healthy.Load())
+	})
+}
+
+// slowPingPool is a connection pool whose ping waits for the release
+type slowPingPool struct {
+	gorm.ConnPool
+	pinging chan struct{}
+	release chan struct{}
+}
+
+func (p *slowPingPool) PingContext(ctx context.Context) error {
+	p.pinging <- struct{}{}
+	<-p.release
+	return context.DeadlineExceeded
+}
+
+func TestReplicaHealthCheck(t *testing.T) {
+	pool := &slowPingPool{pinging: make(chan struct{}), release: make(chan struct{})}
+	replica := &replicaConnPool{ConnPool: pool, address: "replica"}
+	replica.healthy.Store(true)
+
+	checked := make(chan bool)
+	go func() {
+		checked <- replica.isHealthy(context.Background())
+	}()
+	<-pool.pinging
+
+	// the others do not wait for the slow ping
+	done := make(chan bool)
+	go func() {
+		done <- replica.isHealthy(context.Background())
+	}()
+	select {
+	case healthy := <-done:
+		assert.True(t, healthy)
+	case <-time.After(time.Second):
+		t.Fatal("the health check blocks the other queries")
+	}
+
+	close(pool.release)
+	assert.False(t, <-checked)
+	assert.False(t, replica.isHealthy(context.Background()))
+}
+
+func TestReadAfterWriteOnPrimary(t *testing.T) {
+	openMock := func() (*gorm.DB, sqlmock.Sqlmock) {
+		sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherRegexp))
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = sqlDB.Close() })
+		db, err := gorm.Open(mysql.New(mysql.Config{Conn: sqlDB, SkipInitializeWithVersion: true}), &gorm.Config{})
+		assert.NoError(t, err)
+		return db, mock
+	}
+	db, primary := openMock()
+	replicaDB, replica := openMock()
+	assert.NoError(t, db.Use(dbresolver.Register(dbresolver.Config{
+		Replicas: []gorm.Dialector{&replicaDialector{
+			Dialector: mysql.New(mysql.Config{Conn: replicaDB.ConnPool, SkipInitializeWithVersion: true}),
+			address:   "replica",
+			primary:   db.ConnPool,
+		}},
+		Policy: &healthyReplicaPolicy{},
+	})))
+
+	// the replica has no expectations, so the queries fail if they go to it
+	primary.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `history_test_results`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	primary.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `history_test_results` ORDER BY create_time")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("old"))
+	primary.ExpectBegin()
+	primary.ExpectExec(regexp.QuoteMeta("DELETE FROM `history_test_results` WHERE `history_test_results`.`id` = ?")).
+		WithArgs("old").WillReturnResult(sqlmock.NewResult(0, 1))
+	primary.ExpectCommit()
+	primary.ExpectBegin()
+	primary.ExpectExec(regexp.QuoteMeta("INSERT INTO `history_test_results`")).WillReturnResult(sqlmock.NewResult(0, 1))
+	primary.ExpectCommit()
+	assert.NoError(t, createHistory(db, &HistoryTestResult{ID: "new"}, 1))
+
+	primary.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `history_test_results` WHERE id = ?")).WithArgs("new").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("new"))
+	primary.ExpectBegin()
+	primary.ExpectExec(regexp.QuoteMeta("DELETE FROM `history_test_results` WHERE `history_test_results`.`id` = ?")).
+		WithArgs("new").WillReturnResult(sqlmock.NewResult(0, 1))
+	primary.ExpectCommit()
+	assert.NoError(t, deleteHistory(db, &HistoryTestResult{ID: "new"}))
+
+	primary.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `schema_version`")).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	var count int64
+	assert.NoError(t, newSchemaMigrator(db, DialectorMySQL).db.Model(&SchemaVersion{}).Count(&count).Error)
+
+	assert.NoError(t, primary.ExpectationsWereMet())
+	assert.NoError(t, replica.ExpectationsWereMet())
+
+	// the other reads still go to the replica
+	replica.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `history_test_results`")).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	var records []HistoryTestResult
+	assert.NoError(t, db.Find(&records).Error)
+	assert.NoError(t, replica.ExpectationsWereMet())
+}
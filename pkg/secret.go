@@ -0,0 +1,138 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	secretEnvPrefix     = "env:"
+	secretFilePrefix    = "file:"
+	secretCommandPrefix = "cmd:"
+	// secretLiteralPrefix keeps the value which starts with a reference prefix, e.g. literal:file:atest.db
+	secretLiteralPrefix = "literal:"
+)
+
+const secretCommandTimeout = 10 * time.Second
+
+var (
+	secretReferencesEnabled atomic.Bool
+	secretCommandsEnabled   atomic.Bool
+)
+
+// EnableSecretReferences allows the secret references to take the environment variables and files,
+// it is disabled by default because the store properties come from the clients
+func EnableSecretReferences(enabled bool) {
+	secretReferencesEnabled.Store(enabled)
+}
+
+// EnableSecretCommands allows the secret references to take the output of commands, which also needs
+// the secret references enabled. It is disabled by default.
+func EnableSecretCommands(enabled bool) {
+	secretCommandsEnabled.Store(enabled)
+}
+
+// resolveSecret returns the value of a secret reference, or the value itself if it is not a reference.
+// The value is always itself when the secret references are disabled, e.g. a password which starts with env:.
+func resolveSecret(value string) (secret string, isRef bool, err error) {
+	switch {
+	case !secretReferencesEnabled.Load():
+		secret = value
+	case strings.HasPrefix(value, secretLiteralPrefix):
+		secret = strings.TrimPrefix(value, secretLiteralPrefix)
+	case strings.HasPrefix(value, secretEnvPrefix):
+		isRef = true
+		name := strings.TrimPrefix(value, secretEnvPrefix)
+		var ok bool
+		if secret, ok = os.LookupEnv(name); !ok {
+			err = fmt.Errorf("the environment variable %q is not set", name)
+		}
+	case strings.HasPrefix(value, secretFilePrefix):
+		isRef = true
+		var data []byte
+		if data, err = os.ReadFile(strings.TrimPrefix(value, secretFilePrefix)); err == nil {
+			secret = strings.TrimRight(string(data), "\r\n")
+		}
+	case strings.HasPrefix(value, secretCommandPrefix):
+		isRef = true
+		secret, err = runSecretCommand(strings.TrimPrefix(value, secretCommandPrefix))
+	default:
+		secret = value
+	}
+	return
+}
+
+// runSecretCommand runs the command without a shell, and returns its output
+func runSecretCommand(command string) (output string, err error) {
+	if !secretCommandsEnabled.Load() {
+		err = fmt.Errorf("the secret commands are disabled, use the flag --secret-commands to enable them")
+		return
+	}
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		err = fmt.Errorf("the secret command is empty")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), secretCommandTimeout)
+	defer cancel()
+	var data []byte
+	if data, err = exec.CommandContext(ctx, args[0], args[1:]...).Output(); err != nil {
+		err = fmt.Errorf("failed to run the secret command %q: %v", args[0], err)
+		return
+	}
+	output = strings.TrimRight(string(data), "\r\n")
+	return
+}
+
+// secretResolver resolves the secret references, and collects the secrets to redact the logs
+type secretResolver struct {
+	secrets []string
+}
+
+// resolve resolves the reference in place, the redact flag tells whether the value is a secret
+func (r *secretResolver) resolve(name string, value *string, redact bool) (err error) {
+	secret, isRef, err := resolveSecret(*value)
+	if err != nil {
+		err = fmt.Errorf("failed to resolve the secret of %s: %w", name, err)
+		return
+	}
+	if redact && isRef && secret != "" {
+		r.secrets = append(r.secrets, secret)
+	}
+	*value = secret
+	return
+}
+
+// resolveProperties returns a copy of the properties with the references resolved, e.g. the dsn which has
+// the password. The values of the references are redacted since any of them might be a secret.
+func (r *secretResolver) resolveProperties(properties map[string]string) (resolved map[string]string, err error) {
+	resolved = make(map[string]string, len(properties))
+	for key, value := range properties {
+		if err = r.resolve(key, &value, true); err != nil {
+			return
+		}
+		resolved[key] = value
+	}
+	return
+}
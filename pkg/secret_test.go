@@ -0,0 +1,214 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveSecret(t *testing.T) {
+	secretFile := filepath.Join(t.TempDir(), "password")
+	assert.NoError(t, os.WriteFile(secretFile, []byte("from-file\n"), 0o600))
+	t.Setenv("ATEST_ORM_PASSWORD", "from-env")
+
+	tests := []struct {
+		name       string
+		value      string
+		references bool
+		commands   bool
+		expect     string
+		isRef      bool
+		hasErr     bool
+	}{{
+		name:   "plain value",
+		value:  "password",
+		expect: "password",
+	}, {
+		name:       "literal",
+		value:      "literal:file:atest.db?mode=ro",
+		references: true,
+		expect:     "file:atest.db?mode=ro",
+	}, {
+		name:   "literal without the references",
+		value:  "literal:password",
+		expect: "literal:password",
+	}, {
+		name:       "env",
+		value:      "env:ATEST_ORM_PASSWORD",
+		references: true,
+		expect:     "from-env",
+		isRef:      true,
+	}, {
+		name:       "missing env",
+		value:      "env:ATEST_ORM_MISSING",
+		references: true,
+		hasErr:     true,
+	}, {
+		name:   "env without the references",
+		value:  "env:abc",
+		expect: "env:abc",
+	}, {
+		name:       "file",
+		value:      "file:" + secretFile,
+		references: true,
+		expect:     "from-file",
+		isRef:      true,
+	}, {
+		name:       "missing file",
+		value:      "file:" + filepath.Join(t.TempDir(), "missing"),
+		references: true,
+		hasErr:     true,
+	}, {
+		name:   "file without the references",
+		value:  "file:" + secretFile,
+		expect: "file:" + secretFile,
+	}, {
+		name:       "command",
+		value:      "cmd:go env GOOS",
+		references: true,
+		commands:   true,
+		expect:     runtime.GOOS,
+		isRef:      true,
+	}, {
+		name:       "command is disabled",
+		value:      "cmd:go env GOOS",
+		references: true,
+		hasErr:     true,
+	}, {
+		name:     "command without the references",
+		value:    "cmd:go env GOOS",
+		commands: true,
+		expect:   "cmd:go env GOOS",
+	}, {
+		name:       "empty command",
+		value:      "cmd: ",
+		references: true,
+		commands:   true,
+		hasErr:     true,
+	}, {
+		name:       "failed command",
+		value:      "cmd:go fake-command",
+		references: true,
+		commands:   true,
+		hasErr:     true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			EnableSecretReferences(tt.references)
+			EnableSecretCommands(tt.commands)
+			defer EnableSecretReferences(false)
+			defer EnableSecretCommands(false)
+
+			secret, isRef, err := resolveSecret(tt.value)
+			if tt.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, secret)
+				assert.Equal(t, tt.isRef, isRef)
+			}
+		})
+	}
+}
+
+func TestSecretResolver(t *testing.T) {
+	t.Setenv("ATEST_ORM_PASSWORD", "from-env")
+	t.Setenv("ATEST_ORM_KEY", "key-pem")
+	EnableSecretReferences(true)
+	defer EnableSecretReferences(false)
+
+	resolver := &secretResolver{}
+	user, password := "env:ATEST_ORM_PASSWORD", "env:ATEST_ORM_PASSWORD"
+	assert.NoError(t, resolver.resolve("username", &user, false))
+	assert.NoError(t, resolver.resolve("password", &password, true))
+	assert.Equal(t, "from-env", user)
+	assert.Equal(t, "from-env", password)
+
+	// any property can be a reference, the keys from the gRPC metadata are in lower case
+	t.Setenv("ATEST_ORM_DSN", "root:from-env@tcp(localhost:3306)/atest")
+	properties := map[string]string{
+		"sslkey":   "env:ATEST_ORM_KEY",
+		propDSN:    "env:ATEST_ORM_DSN",
+		"database": "literal:file:/data/atest.db?mode=ro",
+		"driver":   "mysql",
+	}
+	resolved, err := resolver.resolveProperties(properties)
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{
+		"sslkey":   "key-pem",
+		propDSN:    "root:from-env@tcp(localhost:3306)/atest",
+		"database": "file:/data/atest.db?mode=ro",
+		"driver":   "mysql",
+	}, resolved)
+	assert.Equal(t, "env:ATEST_ORM_KEY", properties["sslkey"])
+	assert.ElementsMatch(t, []string{"from-env", "key-pem", "root:from-env@tcp(localhost:3306)/atest"}, resolver.secrets)
+
+	_, err = resolver.resolveProperties(map[string]string{propSSLCert: "env:ATEST_ORM_MISSING"})
+	assert.ErrorContains(t, err, propSSLCert)
+}
+
+func TestCreateDBWithSecrets(t *testing.T) {
+	EnableSecretReferences(true)
+	defer EnableSecretReferences(false)
+	dir := t.TempDir()
+	path := filepath.Join(dir, "atest.db")
+
+	db, err := createDB("", "", path, "", "sqlite", map[string]string{
+		propJournalMode: "WAL",
+	})
+	assert.NoError(t, err)
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	assert.NoError(t, sqlDB.Close())
+
+	// the SQLite URI is not a secret file with the literal prefix
+	db, err = createDB("", "", path, "", "sqlite", map[string]string{
+		propDSN:      "literal:file:" + path + "?mode=ro",
+		propReadOnly: "true",
+	})
+	assert.NoError(t, err)
+	sqlDB, err = db.DB()
+	assert.NoError(t, err)
+	assert.NoError(t, sqlDB.Close())
+
+	_, err = createDB("", "file:"+filepath.Join(dir, "missing"), dir, "atest", "sqlite", nil)
+	assert.ErrorContains(t, err, "password")
+}
+
+func TestCreateDBWithoutSecretReferences(t *testing.T) {
+	t.Setenv("ATEST_ORM_PASSWORD", "from-env")
+	path := filepath.Join(t.TempDir(), "atest.db")
+
+	// the values which look like the references are used as they are
+	resolver := &secretResolver{}
+	password := "env:ATEST_ORM_PASSWORD"
+	assert.NoError(t, resolver.resolve("password", &password, true))
+	assert.Equal(t, "env:ATEST_ORM_PASSWORD", password)
+	assert.Empty(t, resolver.secrets)
+
+	db, err := createDB("", "env:abc", path, "", "sqlite", map[string]string{
+		propDSN: "file:" + path + "?mode=rwc",
+	})
+	assert.NoError(t, err)
+	sqlDB, err := db.DB()
+	assert.NoError(t, err)
+	assert.NoError(t, sqlDB.Close())
+}
@@ -20,10 +20,13 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"maps"
 	"os"
 	"path/filepath"
+	"slices"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/linuxsuren/api-testing/pkg/extension"
 	"github.com/linuxsuren/api-testing/pkg/server"
@@ -31,75 +34,144 @@ import (
 	"github.com/linuxsuren/api-testing/pkg/util"
 	"github.com/linuxsuren/api-testing/pkg/version"
 	"gorm.io/driver/mysql"
-	"gorm.io/driver/postgres"
-	"gorm.io/driver/sqlite"
+	"gorm.io/driver/sqlserver"
 	"gorm.io/gorm"
-	"gorm.io/gorm/logger"
 )
 
 type dbserver struct {
 	remote.UnimplementedLoaderServer
 	defaultHistoryLimit int
+	defaultTimezone     string
 }
 
 // NewRemoteServer creates a remote server instance
-func NewRemoteServer(defaultHistoryLimit int) (s remote.LoaderServer) {
+func NewRemoteServer(defaultHistoryLimit int, defaultTimezone string) (s remote.LoaderServer) {
 	s = &dbserver{
 		defaultHistoryLimit: defaultHistoryLimit,
+		defaultTimezone:     defaultTimezone,
 	}
 	return
 }
 
-func createDB(user, password, address, database, driver string) (db *gorm.DB, err error) {
-	var dialector gorm.Dialector
+func createDB(user, password, address, database, driver string, properties map[string]string) (db *gorm.DB, err error) {
+	// the username is an identifier, only the password and properties are redacted
+	resolver := &secretResolver{}
+	if err = resolver.resolve("username", &user, false); err != nil {
+		return
+	}
+	if err = resolver.resolve("password", &password, true); err != nil {
+		return
+	}
+	if properties, err = resolver.resolveProperties(properties); err != nil {
+		return
+	}
+	secrets := append(slices.Clone(resolver.secrets), password)
+
+	var pool poolOptions
+	if pool, err = parsePoolOptions(properties); err != nil {
+		return
+	}
+	var tlsOpts tlsOptions
+	if tlsOpts, err = parseTLSOptions(properties); err != nil {
+		return
+	}
+
 	var dsn string
-	switch driver {
-	case DialectorMySQL, "", "greptime":
-		if !strings.Contains(address, ":") {
-			address = fmt.Sprintf("%s:%d", address, 3306)
+	if dsn, err = buildDSN(user, password, address, database, driver, tlsOpts, properties); err != nil {
+		return
+	}
+
+	var readOnly bool
+	if readOnly, err = parseBoolProperty(properties, propReadOnly); err != nil {
+		return
+	}
+	var sqliteOpts sqliteOptions
+	if driver == "sqlite" || driver == driverSQLitePureGo {
+		if sqliteOpts, err = parseSQLiteOptions(address, database, properties); err != nil {
+			return
 		}
-		dsn = fmt.Sprintf("%s:%s@tcp(%s)/%s?charset=utf8mb4&parseTime=true", user, password, address, database)
-		dialector = mysql.Open(dsn)
-	case "sqlite":
-		dsn = fmt.Sprintf("%s.db", database)
-		dialector = sqlite.Open(dsn)
-	case DialectorPostgres:
-		obj := strings.Split(address, ":")
-		host, port := obj[0], "5432"
-		if len(obj) > 1 {
-			port = obj[1]
+		if err = sqliteOpts.prepare(); err != nil {
+			return
 		}
-		dsn = fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Shanghai", host, user, password, database, port)
-		dialector = postgres.Open(dsn)
-	case "tdengine":
-		dsn = fmt.Sprintf("%s:%s@ws(%s)/%s", user, password, address, database)
-		dialector = NewTDengineDialector(dsn)
-	default:
-		err = fmt.Errorf("invalid database driver %q", driver)
+	}
+
+	var dialector gorm.Dialector
+	if dialector, err = newDialector(driver, dsn, tlsOpts, properties); err != nil {
 		return
 	}
 
-	log.Printf("try to connect to %q", dsn)
+	log.Printf("try to connect to %q", redactSecrets(redactDSN(dsn), secrets...))
 	db, err = gorm.Open(dialector, &gorm.Config{
-		Logger: logger.Default.LogMode(logger.Info),
+		Logger: newRedactLogger(secrets...),
 	})
 	if err != nil {
-		err = fmt.Errorf("failed to connect to %q %v", dsn, err)
+		err = fmt.Errorf("failed to connect to %q %v", redactSecrets(redactDSN(dsn), secrets...), redactSecrets(err.Error(), secrets...))
+		return
+	}
+
+	if sqliteOpts.Memory {
+		// the held connection does not count towards the pool size
+		if pool.MaxOpenConns > 0 {
+			pool.MaxOpenConns++
+		}
+		if err = holdMemoryConn(db); err != nil {
+			return
+		}
+	}
+	if err = pool.apply(db); err != nil {
+		return
+	}
+
+	var loc *time.Location
+	if loc, err = loadTimezone(properties, ""); err != nil {
+		return
+	}
+
+	// the migrations run before registering the replicas, so they only go to the primary
+	migrator := newSchemaMigrator(db, driver).inTimezone(loc)
+	if isAutoMigrate(properties) && !readOnly {
+		_, err = migrator.Up(0, false)
+	} else {
+		err = migrator.Check()
+	}
+	if err != nil {
 		return
 	}
+	err = useReplicas(db, user, password, database, driver, tlsOpts, pool, properties, secrets...)
+	return
+}
 
-	if driver != "tdengine" && driver != "greptime" {
-		err = errors.Join(err, db.AutoMigrate(&TestCase{}))
-		err = errors.Join(err, db.AutoMigrate(&TestSuite{}))
-		err = errors.Join(err, db.AutoMigrate(&HistoryTestResult{}))
+func newDialector(driver, dsn string, tlsOpts tlsOptions, properties map[string]string) (dialector gorm.Dialector, err error) {
+	switch driver {
+	case DialectorMySQL, "":
+		dialector = mysql.Open(dsn)
+	case DialectorGreptime:
+		dialector, err = newGreptimeDialector(dsn, tlsOpts, properties)
+	case "sqlite", driverSQLitePureGo:
+		dialector = openSQLite(driver, dsn)
+	case DialectorPostgres:
+		dialector, err = newPostgresDialector(dsn, tlsOpts)
+	case DialectorSQLServer:
+		dialector = sqlserver.Open(dsn)
+	case DialectorClickHouse:
+		dialector, err = newClickHouseDialector(dsn, tlsOpts)
+	case DialectorDuckDB:
+		dialector, err = newDuckDBDialector(dsn)
+	case DialectorTDengine:
+		dialector = NewTDengineDialector(dsn)
 	}
 	return
 }
 
-var dbCache = make(map[string]*gorm.DB)
-var dbNameCache = make(map[string]string)
+// getClientWithDatabase returns the client, and the function which releases its connection
+// pool once the caller is done with it
+func (s *dbserver) getClientWithDatabase(ctx context.Context, dbName string) (dbQuery DataQuery, release func(), err error) {
+	dbQuery, _, release, err = s.getPooledClient(ctx, dbName)
+	return
+}
 
-func (s *dbserver) getClientWithDatabase(ctx context.Context, dbName string) (dbQuery DataQuery, err error) {
+// getPooledClient returns the client along with the key of its connection pool
+func (s *dbserver) getPooledClient(ctx context.Context, dbName string) (dbQuery DataQuery, key string, release func(), err error) {
 	store := remote.GetStoreFromContext(ctx)
 	if store == nil {
 		err = errors.New("no connect to database")
@@ -111,31 +183,65 @@ func (s *dbserver) getClientWithDatabase(ctx context.Context, dbName string) (db
 			}
 		}
 
-		driver := DialectorMySQL
-		if v, ok := store.Properties["driver"]; ok && v != "" {
-			driver = v
-		}
+		driver := storeDriver(store.Properties)
 		log.Printf("get client from driver[%s] in database [%s]", driver, database)
 
-		var ok bool
-		var db *gorm.DB
-		if db, ok = dbCache[store.Name]; (ok && db != nil && dbNameCache[store.Name] != database) || !ok {
-			if db, err = createDB(store.Username, store.Password, store.URL, database, driver); err == nil {
-				dbCache[store.Name] = db
-				dbNameCache[store.Name] = database
-			} else {
-				return
+		// copy the properties, the store comes from the request context
+		properties := maps.Clone(store.Properties)
+		if _, ok := getProperty(properties, propTimezone); !ok && s.defaultTimezone != "" {
+			if properties == nil {
+				properties = map[string]string{}
 			}
+			properties[propTimezone] = s.defaultTimezone
+		}
+
+		poolDatabase := database
+		if driver == DialectorDuckDB {
+			// the catalogs are attached to one embedded database, which cannot be opened twice
+			poolDatabase = ""
+		}
+		key = connKey(store.Name, poolDatabase, driver, store.URL, store.Username, store.Password,
+			fmt.Sprint(properties))
+		get := dbPool.Get
+		if isMemoryDatabase(driver, store.URL) {
+			// the data is gone with the pool
+			get = dbPool.GetPinned
+		}
+		var db *gorm.DB
+		if db, release, err = get(key, func() (*gorm.DB, error) {
+			return createDB(store.Username, store.Password, store.URL, database, driver, properties)
+		}); err != nil {
+			return
 		}
 
-		dbQuery = NewCommonDataQuery(GetInnerSQL(driver), db)
+		dbQuery = NewDataQuery(driver, db)
 	}
 	return
 }
 
-func (s *dbserver) getClient(ctx context.Context) (db *gorm.DB, err error) {
+// storeDriver returns the driver of the store, the default is mysql
+func storeDriver(properties map[string]string) (driver string) {
+	driver = DialectorMySQL
+	if v, ok := properties["driver"]; ok && v != "" {
+		driver = v
+	}
+	return
+}
+
+// isMemoryDatabase reports whether the store is an in-memory SQLite or DuckDB database
+func isMemoryDatabase(driver, address string) bool {
+	switch driver {
+	case "sqlite", driverSQLitePureGo:
+		return address == SQLiteMemory
+	case DialectorDuckDB:
+		return address == "" || address == SQLiteMemory
+	}
+	return false
+}
+
+func (s *dbserver) getClient(ctx context.Context) (db *gorm.DB, release func(), err error) {
 	var dbQuery DataQuery
-	if dbQuery, err = s.getClientWithDatabase(ctx, ""); err == nil {
+	if dbQuery, release, err = s.getClientWithDatabase(ctx, ""); err == nil {
 		db = dbQuery.GetClient()
 	}
 	return
@@ -145,9 +251,11 @@ func (s *dbserver) ListTestSuite(ctx context.Context, _ *server.Empty) (suites *
 	items := make([]*TestSuite, 0)
 
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
+	defer release()
 
 	if err = db.Find(&items).Error; err == nil {
 		suites = &remote.TestSuites{}
@@ -167,28 +275,34 @@ func (s *dbserver) ListTestSuite(ctx context.Context, _ *server.Empty) (suites *
 func (s *dbserver) CreateTestSuite(ctx context.Context, testSuite *remote.TestSuite) (reply *server.Empty, err error) {
 	reply = &server.Empty{}
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
+	defer release()
 
 	err = db.Create(ConvertToDBTestSuite(testSuite)).Error
 	return
 }
 
 const (
-	nameQuery      = `name = ?`
-	suiteNameQuery = "suite_name = ?"
-	idQuery        = "id = ?"
+	nameQuery                = `name = ?`
+	suiteNameQuery           = "suite_name = ?"
+	idQuery                  = "id = ?"
+	suiteAndCaseNameQuery    = "suite_name = ? AND name = ?"
+	suiteAndHistoryCaseQuery = "suite_name = ? AND case_name = ?"
 )
 
 func (s *dbserver) GetTestSuite(ctx context.Context, suite *remote.TestSuite) (reply *remote.TestSuite, err error) {
 	query := &TestSuite{}
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
+	defer release()
 
-	if err = db.Find(&query, nameQuery, suite.Name).Error; err == nil {
+	if err = testSuiteIdentity(db, &TestSuite{Name: suite.Name}).Find(&query).Error; err == nil {
 		reply = ConvertToGRPCTestSuite(query)
 		if suite.Full {
 			var testcases *server.TestCases
@@ -206,11 +320,17 @@ func (s *dbserver) UpdateTestSuite(ctx context.Context, suite *remote.TestSuite)
 	reply = &remote.TestSuite{}
 	input := ConvertToDBTestSuite(suite)
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
+	defer release()
 
-	err = testSuiteIdentity(db, input).Updates(input).Error
+	if isTDengine(db) {
+		err = updateByTimestamp(testSuiteIdentity(db, input), input)
+	} else {
+		err = testSuiteIdentity(db, input).Updates(input).Error
+	}
 	return
 }
 
@@ -221,14 +341,23 @@ func testSuiteIdentity(db *gorm.DB, suite *TestSuite) *gorm.DB {
 func (s *dbserver) DeleteTestSuite(ctx context.Context, suite *remote.TestSuite) (reply *server.Empty, err error) {
 	reply = &server.Empty{}
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
+	defer release()
 
+	if isTDengine(db) {
+		// there is no transaction in TDengine
+		if err = deleteByTimestamp(testSuiteIdentity(db, &TestSuite{Name: suite.Name}), &TestSuite{}); err == nil {
+			err = deleteByTimestamp(db.Model(&TestCase{}).Where(suiteNameQuery, suite.Name), &TestCase{})
+		}
+		return
+	}
 	err = db.Transaction(func(tx *gorm.DB) (err error) {
-		err = db.Delete(TestSuite{}, nameQuery, suite.Name).Error
+		err = tx.Delete(TestSuite{}, nameQuery, suite.Name).Error
 		if err == nil {
-			err = db.Delete(TestCase{}, suiteNameQuery, suite.Name).Error
+			err = tx.Delete(TestCase{}, suiteNameQuery, suite.Name).Error
 		}
 		return
 	})
@@ -238,9 +367,11 @@ func (s *dbserver) DeleteTestSuite(ctx context.Context, suite *remote.TestSuite)
 func (s *dbserver) ListTestCases(ctx context.Context, suite *remote.TestSuite) (result *server.TestCases, err error) {
 	items := make([]*TestCase, 0)
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
+	defer release()
 	if err = db.Find(&items, suiteNameQuery, suite.Name).Error; err == nil {
 		result = &server.TestCases{}
 		for i := range items {
@@ -253,9 +384,11 @@ func (s *dbserver) ListTestCases(ctx context.Context, suite *remote.TestSuite) (
 func (s *dbserver) CreateTestCase(ctx context.Context, testcase *server.TestCase) (reply *server.Empty, err error) {
 	payload := ConverToDBTestCase(testcase)
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
+	defer release()
 	reply = &server.Empty{}
 	err = db.Create(&payload).Error
 	return
@@ -264,13 +397,15 @@ func (s *dbserver) CreateTestCase(ctx context.Context, testcase *server.TestCase
 func (s *dbserver) CreateTestCaseHistory(ctx context.Context, historyTestResult *server.HistoryTestResult) (reply *server.Empty, err error) {
 	reply = &server.Empty{}
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
+	defer release()
 
 	store := remote.GetStoreFromContext(ctx)
 	historyLimit := s.defaultHistoryLimit
-	if v, ok := store.Properties["historyLimit"]; ok {
+	if v, ok := getProperty(store.Properties, "historyLimit"); ok {
 		if parsedHistoryLimit, parseErr := strconv.Atoi(v); parseErr == nil {
 			historyLimit = parsedHistoryLimit
 		} else {
@@ -278,6 +413,18 @@ func (s *dbserver) CreateTestCaseHistory(ctx context.Context, historyTestResult
 		}
 	}
 
+	var loc *time.Location
+	if loc, err = loadTimezone(store.Properties, s.defaultTimezone); err != nil {
+		return
+	}
+	err = createHistory(db, ConvertToDBHistoryTestResult(historyTestResult, loc), historyLimit)
+	return
+}
+
+// createHistory removes the oldest record when reaching the limit, then creates the record.
+// All the queries go to the primary, the count of a replica might be stale.
+func createHistory(db *gorm.DB, record *HistoryTestResult, historyLimit int) (err error) {
+	db = primaryDB(db)
 	var count int64
 	if err = db.Model(&HistoryTestResult{}).Count(&count).Error; err != nil {
 		return
@@ -290,14 +437,19 @@ func (s *dbserver) CreateTestCaseHistory(ctx context.Context, historyTestResult
 			return
 		}
 
-		if err = db.Delete(&oldestRecord).Error; err != nil {
+		if isTDengine(db) {
+			err = deleteByTimestamp(historyTestCaseIdentity(db, &oldestRecord), &HistoryTestResult{})
+		} else {
+			err = db.Delete(&oldestRecord).Error
+		}
+		if err != nil {
 			log.Printf("Error delete oldest record: %v\n", err)
 			return
 		}
 		log.Printf("Existing count: %d, limit: %d\nmaximum number of entries reached.\n", count, historyLimit)
 	}
 
-	err = db.Create(ConvertToDBHistoryTestResult(historyTestResult)).Error
+	err = db.Create(record).Error
 	return
 }
 
@@ -305,9 +457,11 @@ func (s *dbserver) ListHistoryTestSuite(ctx context.Context, _ *server.Empty) (s
 	items := make([]*HistoryTestResult, 0)
 
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
+	defer release()
 
 	if err = db.Find(&items).Error; err != nil {
 		return
@@ -336,10 +490,12 @@ func (s *dbserver) ListHistoryTestSuite(ctx context.Context, _ *server.Empty) (s
 func (s *dbserver) GetTestCase(ctx context.Context, testcase *server.TestCase) (result *server.TestCase, err error) {
 	item := &TestCase{}
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
-	if err = db.Find(&item, "suite_name = ? AND name = ?", testcase.SuiteName, testcase.Name).Error; err == nil {
+	defer release()
+	if err = testCaseIdentity(db, &TestCase{SuiteName: testcase.SuiteName, Name: testcase.Name}).Find(&item).Error; err == nil {
 		result = ConvertToRemoteTestCase(item)
 	}
 	return
@@ -348,10 +504,12 @@ func (s *dbserver) GetTestCase(ctx context.Context, testcase *server.TestCase) (
 func (s *dbserver) GetHistoryTestCaseWithResult(ctx context.Context, testcase *server.HistoryTestCase) (result *server.HistoryTestResult, err error) {
 	item := &HistoryTestResult{}
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
-	if err = db.Find(&item, idQuery, testcase.ID).Error; err == nil {
+	defer release()
+	if err = historyTestCaseIdentity(db, &HistoryTestResult{ID: testcase.ID}).Find(&item).Error; err == nil {
 		result = ConvertToRemoteHistoryTestResult(item)
 	}
 	return
@@ -360,11 +518,13 @@ func (s *dbserver) GetHistoryTestCaseWithResult(ctx context.Context, testcase *s
 func (s *dbserver) GetHistoryTestCase(ctx context.Context, testcase *server.HistoryTestCase) (result *server.HistoryTestCase, err error) {
 	item := &HistoryTestResult{}
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
+	defer release()
 
-	if err = db.Find(&item, idQuery, testcase.ID).Error; err == nil {
+	if err = historyTestCaseIdentity(db, &HistoryTestResult{ID: testcase.ID}).Find(&item).Error; err == nil {
 		result = ConvertToGRPCHistoryTestCase(item)
 	}
 	return
@@ -373,10 +533,12 @@ func (s *dbserver) GetHistoryTestCase(ctx context.Context, testcase *server.Hist
 func (s *dbserver) GetTestCaseAllHistory(ctx context.Context, testcase *server.TestCase) (result *server.HistoryTestCases, err error) {
 	items := make([]*HistoryTestResult, 0)
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
-	if err = db.Find(&items, "suite_name = ? AND case_name = ? ", testcase.SuiteName, testcase.Name).Error; err == nil {
+	defer release()
+	if err = allHistoryTestCaseIdentity(db, &HistoryTestResult{SuiteName: testcase.SuiteName, CaseName: testcase.Name}).Find(&items).Error; err == nil {
 		result = &server.HistoryTestCases{}
 		for i := range items {
 			result.Data = append(result.Data, ConvertToGRPCHistoryTestCase(items[i]))
@@ -389,10 +551,12 @@ func (s *dbserver) UpdateTestCase(ctx context.Context, testcase *server.TestCase
 	reply = &server.TestCase{}
 	input := ConverToDBTestCase(testcase)
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
-	if err = testCaseIdentity(db, input).Updates(input).Error; err != nil {
+	defer release()
+	if err = updateTestCase(db, input, input); err != nil {
 		return
 	}
 
@@ -405,19 +569,33 @@ func (s *dbserver) UpdateTestCase(ctx context.Context, testcase *server.TestCase
 	}
 
 	if len(data) > 0 {
-		err = testCaseIdentity(db, input).Updates(data).Error
+		err = updateTestCase(db, input, data)
 	}
 	return
 }
 
+// updateTestCase updates the test case like Updates, TDengine overwrites the rows instead
+func updateTestCase(db *gorm.DB, testcase *TestCase, values interface{}) error {
+	if isTDengine(db) {
+		return updateByTimestamp(testCaseIdentity(db, testcase), values)
+	}
+	return testCaseIdentity(db, testcase).Updates(values).Error
+}
+
 func (s *dbserver) DeleteTestCase(ctx context.Context, testcase *server.TestCase) (reply *server.Empty, err error) {
 	reply = &server.Empty{}
 	input := ConverToDBTestCase(testcase)
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
-	err = testCaseIdentity(db, input).Delete(input).Error
+	defer release()
+	if isTDengine(db) {
+		err = deleteByTimestamp(testCaseIdentity(db, input), &TestCase{})
+	} else {
+		err = testCaseIdentity(db, input).Delete(input).Error
+	}
 	return
 }
 
@@ -427,12 +605,23 @@ func (s *dbserver) DeleteHistoryTestCase(ctx context.Context, historyTestCase *s
 		ID: historyTestCase.ID,
 	}
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
+	defer release()
+	if err = deleteHistory(db, input); err != nil {
+		reply = nil
+	}
+	return
+}
+
+// deleteHistory deletes the record and its body file, the record is found in the primary
+func deleteHistory(db *gorm.DB, input *HistoryTestResult) (err error) {
+	db = primaryDB(db)
 	var historyTestResult HistoryTestResult
 	if err = historyTestCaseIdentity(db, input).Find(&historyTestResult).Error; err != nil {
-		return nil, err
+		return
 	}
 	fileName := historyTestResult.Body
 	if strings.HasPrefix(fileName, "isFilePath-") {
@@ -443,7 +632,11 @@ func (s *dbserver) DeleteHistoryTestCase(ctx context.Context, historyTestCase *s
 			return
 		}
 	}
-	err = db.Delete(&historyTestResult).Error
+	if isTDengine(db) {
+		err = deleteByTimestamp(historyTestCaseIdentity(db, &historyTestResult), &HistoryTestResult{})
+	} else {
+		err = db.Delete(&historyTestResult).Error
+	}
 	return
 }
 
@@ -454,10 +647,14 @@ func (s *dbserver) DeleteAllHistoryTestCase(ctx context.Context, historyTestCase
 		CaseName:  historyTestCase.CaseName,
 	}
 	var db *gorm.DB
-	if db, err = s.getClient(ctx); err != nil {
+	var release func()
+	if db, release, err = s.getClient(ctx); err != nil {
 		return
 	}
+	defer release()
 
+	// the records to delete are found in the primary
+	db = primaryDB(db)
 	var historyTestResults []HistoryTestResult
 	if err = allHistoryTestCaseIdentity(db, input).Find(&historyTestResults).Error; err != nil {
 		return nil, err
@@ -473,7 +670,11 @@ func (s *dbserver) DeleteAllHistoryTestCase(ctx context.Context, historyTestCase
 				continue
 			}
 		}
-		db.Delete(&historyTestResult)
+		if isTDengine(db) {
+			_ = deleteByTimestamp(historyTestCaseIdentity(db, &historyTestResult), &HistoryTestResult{})
+		} else {
+			db.Delete(&historyTestResult)
+		}
 	}
 	return
 }
@@ -486,12 +687,26 @@ func (s *dbserver) Verify(ctx context.Context, in *server.Empty) (reply *server.
 
 	var vErr error
 	var dbQuery DataQuery
-	if dbQuery, err = s.getClientWithDatabase(ctx, ""); err == nil {
-		_, vErr = dbQuery.GetDatabases(ctx)
+	var pool poolOptions
+	var key string
+	var release func()
+	if dbQuery, key, release, err = s.getPooledClient(ctx, ""); err != nil {
+		return
 	}
+	defer release()
+	start := time.Now()
+	_, vErr = dbQuery.GetDatabases(ctx)
+	health := dbPool.Record(key, time.Since(start), vErr)
+	store := remote.GetStoreFromContext(ctx)
+	pool, _ = parsePoolOptions(store.Properties)
 
 	reply.Ready = vErr == nil
-	reply.Message = util.OKOrErrorMessage(vErr)
+	reply.Message = fmt.Sprintf("%s; health: %s", util.OrErrorMessage(vErr, fmt.Sprintf("OK; %s", pool)), health)
+	if warning := plaintextCredentialsWarning(store.Properties, store.Password); warning != "" {
+		log.Println(warning)
+		reply.Message = fmt.Sprintf("%s; %s", reply.Message, warning)
+	}
+	log.Printf("connection pools: %s", dbPool.Stats())
 	return
 }
 
@@ -514,13 +729,27 @@ func (s *dbserver) PProf(ctx context.Context, in *server.PProfRequest) (data *se
 }
 
 func testCaseIdentity(db *gorm.DB, testcase *TestCase) *gorm.DB {
-	return db.Model(testcase).Where(fmt.Sprintf("suite_name = '%s' AND name = '%s'", testcase.SuiteName, testcase.Name))
+	return db.Model(testcase).Where(suiteAndCaseNameQuery, testcase.SuiteName, testcase.Name)
 }
 
 func historyTestCaseIdentity(db *gorm.DB, historyTestResult *HistoryTestResult) *gorm.DB {
-	return db.Model(historyTestResult).Where(fmt.Sprintf("id = '%s'", historyTestResult.ID))
+	return db.Model(historyTestResult).Where(idQuery, historyTestResult.ID)
 }
 
 func allHistoryTestCaseIdentity(db *gorm.DB, historyTestResult *HistoryTestResult) *gorm.DB {
-	return db.Model(historyTestResult).Where(fmt.Sprintf("suite_name = '%s' AND case_name = '%s'", historyTestResult.SuiteName, historyTestResult.CaseName))
+	return db.Model(historyTestResult).Where(suiteAndHistoryCaseQuery, historyTestResult.SuiteName, historyTestResult.CaseName)
+}
+
+// getProperty returns the value of a store property. The key is case-insensitive
+// because the properties are passed via gRPC metadata which lowercases the keys.
+func getProperty(properties map[string]string, key string) (val string, ok bool) {
+	if val, ok = properties[key]; ok {
+		return
+	}
+	for k, v := range properties {
+		if strings.EqualFold(k, key) {
+			return v, true
+		}
+	}
+	return
 }
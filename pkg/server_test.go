@@ -19,8 +19,10 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
+	"unicode/utf8"
 
 	"github.com/linuxsuren/api-testing/pkg/server"
 	atest "github.com/linuxsuren/api-testing/pkg/testing"
@@ -30,7 +32,7 @@ import (
 )
 
 func TestNewRemoteServer(t *testing.T) {
-	remoteServer := NewRemoteServer(10)
+	remoteServer := NewRemoteServer(10, "")
 	assert.NotNil(t, remoteServer)
 	defaultCtx := context.Background()
 
@@ -120,7 +122,7 @@ func TestNewRemoteServer(t *testing.T) {
 	})
 
 	t.Run("invalid orm driver", func(t *testing.T) {
-		remoteServer := NewRemoteServer(10)
+		remoteServer := NewRemoteServer(10, "")
 		assert.NotNil(t, remoteServer)
 		defaultCtx := remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
 			Properties: map[string]string{
@@ -132,7 +134,7 @@ func TestNewRemoteServer(t *testing.T) {
 	})
 
 	t.Run("invalid mysql config", func(t *testing.T) {
-		remoteServer := NewRemoteServer(10)
+		remoteServer := NewRemoteServer(10, "")
 		assert.NotNil(t, remoteServer)
 		defaultCtx := remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
 			Properties: map[string]string{
@@ -144,7 +146,7 @@ func TestNewRemoteServer(t *testing.T) {
 	})
 
 	t.Run("invalid postgres config", func(t *testing.T) {
-		remoteServer := NewRemoteServer(10)
+		remoteServer := NewRemoteServer(10, "")
 		assert.NotNil(t, remoteServer)
 		defaultCtx := remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
 			Properties: map[string]string{
@@ -158,7 +160,7 @@ func TestNewRemoteServer(t *testing.T) {
 }
 
 func TestSQLite(t *testing.T) {
-	remoteServer := NewRemoteServer(10)
+	remoteServer := NewRemoteServer(10, "")
 	assert.NotNil(t, remoteServer)
 	defaultCtx := remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
 		Properties: map[string]string{
@@ -258,6 +260,34 @@ func TestSQLite(t *testing.T) {
 		assert.NoError(t, err)
 	})
 
+	t.Run("apply pool options", func(t *testing.T) {
+		ctx := remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
+			Properties: map[string]string{
+				"driver":       "sqlite",
+				"database":     "atest",
+				"maxOpenConns": "5",
+			},
+		})
+		db, release, err := remoteServer.(*dbserver).getClient(ctx)
+		assert.NoError(t, err)
+		defer release()
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+		assert.Equal(t, 5, sqlDB.Stats().MaxOpenConnections)
+	})
+
+	t.Run("invalid pool options", func(t *testing.T) {
+		ctx := remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
+			Properties: map[string]string{
+				"driver":       "sqlite",
+				"database":     "atest",
+				"maxIdleConns": "invalid",
+			},
+		})
+		_, err := remoteServer.ListTestSuite(ctx, &server.Empty{})
+		assert.Error(t, err)
+	})
+
 	now := time.Now()
 	t.Run("CreateTestCaseHistory", func(t *testing.T) {
 		_, err := remoteServer.CreateTestCaseHistory(defaultCtx, &server.HistoryTestResult{
@@ -324,7 +354,7 @@ func TestSQLite(t *testing.T) {
 }
 
 func TestTdEngine(t *testing.T) {
-	remoteServer := NewRemoteServer(10)
+	remoteServer := NewRemoteServer(10, "")
 	assert.NotNil(t, remoteServer)
 	defaultCtx := remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
 		URL:      "127.0.0.1:6041",
@@ -337,3 +367,78 @@ func TestTdEngine(t *testing.T) {
 	_, err := remoteServer.Query(defaultCtx, &server.DataQuery{})
 	assert.Error(t, err)
 }
+
+func FuzzTestCaseIdentity(f *testing.F) {
+	for _, name := range []string{
+		"simple",
+		"it's",
+		`"double"`,
+		`back\slash\`,
+		"' OR '1'='1",
+		"'; DROP TABLE test_cases; --",
+		"SELECT * FROM test_suites",
+		"名字-テスト-🚀",
+		"%_wildcard_%",
+	} {
+		f.Add(name, name+" case")
+	}
+
+	remoteServer := NewRemoteServer(10, "")
+	ctx := remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
+		Name: "fuzz",
+		Properties: map[string]string{
+			"driver":   "sqlite",
+			"database": filepath.Join(f.TempDir(), "fuzz"),
+		},
+	})
+
+	f.Fuzz(func(t *testing.T, suiteName, caseName string) {
+		if suiteName == "" || caseName == "" || !utf8.ValidString(suiteName) || !utf8.ValidString(caseName) {
+			t.Skip()
+		}
+
+		_, err := remoteServer.CreateTestSuite(ctx, &remote.TestSuite{Name: suiteName})
+		assert.NoError(t, err)
+		_, err = remoteServer.CreateTestCase(ctx, &server.TestCase{SuiteName: suiteName, Name: caseName})
+		assert.NoError(t, err)
+
+		_, err = remoteServer.UpdateTestSuite(ctx, &remote.TestSuite{Name: suiteName, Api: "api"})
+		assert.NoError(t, err)
+		suite, err := remoteServer.GetTestSuite(ctx, &remote.TestSuite{Name: suiteName})
+		assert.NoError(t, err)
+		assert.Equal(t, "api", suite.Api)
+
+		_, err = remoteServer.UpdateTestCase(ctx, &server.TestCase{
+			SuiteName: suiteName,
+			Name:      caseName,
+			Request:   &server.Request{Api: "api"},
+		})
+		assert.NoError(t, err)
+		testcase, err := remoteServer.GetTestCase(ctx, &server.TestCase{SuiteName: suiteName, Name: caseName})
+		assert.NoError(t, err)
+		assert.Equal(t, "api", testcase.Request.Api)
+
+		_, err = remoteServer.CreateTestCaseHistory(ctx, &server.HistoryTestResult{
+			CreateTime: timestamppb.Now(),
+			Data:       &server.HistoryTestCase{SuiteName: suiteName, CaseName: caseName},
+		})
+		assert.NoError(t, err)
+		histories, err := remoteServer.GetTestCaseAllHistory(ctx, &server.TestCase{SuiteName: suiteName, Name: caseName})
+		assert.NoError(t, err)
+		assert.Len(t, histories.Data, 1)
+		_, err = remoteServer.DeleteAllHistoryTestCase(ctx, &server.HistoryTestCase{SuiteName: suiteName, CaseName: caseName})
+		assert.NoError(t, err)
+
+		_, err = remoteServer.DeleteTestCase(ctx, &server.TestCase{SuiteName: suiteName, Name: caseName})
+		assert.NoError(t, err)
+		testcases, err := remoteServer.ListTestCases(ctx, &remote.TestSuite{Name: suiteName})
+		assert.NoError(t, err)
+		assert.Empty(t, testcases.Data)
+
+		_, err = remoteServer.DeleteTestSuite(ctx, &remote.TestSuite{Name: suiteName})
+		assert.NoError(t, err)
+		suites, err := remoteServer.ListTestSuite(ctx, &server.Empty{})
+		assert.NoError(t, err)
+		assert.Empty(t, suites.Data)
+	})
+}
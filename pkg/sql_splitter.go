@@ -0,0 +1,172 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"regexp"
+	"strings"
+)
+
+const defaultDelimiter = ";"
+
+// splitOptions represents the lexical rules of a dialect which affect the statement boundaries
+type splitOptions struct {
+	// backslashEscapes allows escaping the quote by a backslash, e.g. 'it\'s'
+	backslashEscapes bool
+	// hashComments treats # as the start of a line comment
+	hashComments bool
+	// dollarQuotes supports the dollar-quoted strings, e.g. $$ ... $$ or $body$ ... $body$
+	dollarQuotes bool
+	// bracketIdentifiers supports the identifiers quoted by brackets, e.g. [order]
+	bracketIdentifiers bool
+	// delimiterCommand supports the client command DELIMITER which changes the statement delimiter
+	delimiterCommand bool
+}
+
+func splitOptionsOf(dialect string) (opts splitOptions) {
+	switch dialect {
+	case DialectorMySQL:
+		opts = splitOptions{backslashEscapes: true, hashComments: true, delimiterCommand: true}
+	case DialectorClickHouse, DialectorTDengine:
+		opts.backslashEscapes = true
+	case DialectorPostgres, DialectorDuckDB:
+		opts.dollarQuotes = true
+	case DialectorSQLServer:
+		opts.bracketIdentifiers = true
+	}
+	return
+}
+
+var (
+	dollarQuoteTag   = regexp.MustCompile(`^\$([A-Za-z_][A-Za-z0-9_]*)?\$`)
+	delimiterCommand = regexp.MustCompile(`(?i)^DELIMITER[ \t]+(\S+)[ \t]*(\r?\n|$)`)
+)
+
+// splitStatements splits the SQL text into statements by the delimiter, which is ; by default.
+// The delimiters inside the quotes, comments and dollar-quoted strings are ignored,
+// and the segments which only have comments are dropped.
+func splitStatements(sqlText, dialect string) (statements []string) {
+	opts := splitOptionsOf(dialect)
+	delimiter := defaultDelimiter
+
+	var current strings.Builder
+	hasCode := false
+	flush := func() {
+		if hasCode {
+			statements = append(statements, strings.TrimSpace(current.String()))
+		}
+		current.Reset()
+		hasCode = false
+	}
+
+	for i := 0; i < len(sqlText); {
+		rest := sqlText[i:]
+		if opts.delimiterCommand && !hasCode && isLineStart(sqlText, i) {
+			if matches := delimiterCommand.FindStringSubmatch(rest); matches != nil {
+				delimiter = matches[1]
+				current.Reset()
+				i += len(matches[0])
+				continue
+			}
+		}
+		if strings.HasPrefix(rest, delimiter) {
+			flush()
+			i += len(delimiter)
+			continue
+		}
+
+		// the quotes and comments are kept as a whole, and only the comments are not code
+		end := skippedEnd(sqlText, i, opts)
+		code := !isCommentStart(rest, opts)
+		if end == 0 {
+			end = 1
+			code = !strings.ContainsRune(" \t\r\n", rune(rest[0]))
+		}
+
+		current.WriteString(rest[:end])
+		hasCode = hasCode || code
+		i += end
+	}
+	flush()
+	return
+}
+
+// quotedEnd returns the length of the quoted text which starts at the beginning of text,
+// the doubled closing quote is treated as an escaped one
+func quotedEnd(text string, closing byte, backslashEscapes bool) int {
+	for i := 1; i < len(text); i++ {
+		switch text[i] {
+		case '\\':
+			if backslashEscapes {
+				i++
+			}
+		case closing:
+			if i+1 < len(text) && text[i+1] == closing {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// skippedEnd returns the length of the quoted text or comment which starts at text[i],
+// or 0 if there is none. The unterminated quote or comment lasts to the end.
+func skippedEnd(text string, i int, opts splitOptions) (end int) {
+	rest := text[i:]
+	switch c := rest[0]; {
+	case c == '\'' || c == '"' || c == '`':
+		end = quotedEnd(rest, c, opts.backslashEscapes && c != '`')
+	case c == '[' && opts.bracketIdentifiers:
+		end = quotedEnd(rest, ']', false)
+	case strings.HasPrefix(rest, "--") || (c == '#' && opts.hashComments):
+		end = strings.IndexByte(rest, '\n')
+	case strings.HasPrefix(rest, "/*"):
+		if end = strings.Index(rest[2:], "*/"); end >= 0 {
+			end += 4
+		}
+	case c == '$' && opts.dollarQuotes && !isIdentifierChar(text, i-1):
+		if tag := dollarQuoteTag.FindString(rest); tag != "" {
+			if end = strings.Index(rest[len(tag):], tag); end >= 0 {
+				end += 2 * len(tag)
+			}
+		}
+	}
+	if end < 0 {
+		end = len(rest)
+	}
+	return
+}
+
+// isCommentStart tells if a line or block comment starts at the beginning of text
+func isCommentStart(text string, opts splitOptions) bool {
+	return strings.HasPrefix(text, "--") || strings.HasPrefix(text, "/*") ||
+		(opts.hashComments && strings.HasPrefix(text, "#"))
+}
+
+func isLineStart(text string, i int) bool {
+	lineStart := strings.LastIndexByte(text[:i], '\n') + 1
+	return strings.TrimSpace(text[lineStart:i]) == ""
+}
+
+func isIdentifierChar(text string, i int) bool {
+	if i < 0 {
+		return false
+	}
+	c := text[i]
+	return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
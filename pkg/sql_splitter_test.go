@@ -0,0 +1,91 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitStatements(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect string
+		sql     string
+		expect  []string
+	}{{
+		name:   "empty",
+		sql:    " ;\n; ",
+		expect: nil,
+	}, {
+		name:   "simple",
+		sql:    "SELECT 1; SELECT 2;",
+		expect: []string{"SELECT 1", "SELECT 2"},
+	}, {
+		name:   "semicolon in literals",
+		sql:    `SELECT 'a;b', "c;d", 'it''s;'; SELECT 2`,
+		expect: []string{`SELECT 'a;b', "c;d", 'it''s;'`, "SELECT 2"},
+	}, {
+		name:   "semicolon in comments",
+		sql:    "SELECT 1 -- first; statement\n; /* a; b */ SELECT 2; -- the end;",
+		expect: []string{"SELECT 1 -- first; statement", "/* a; b */ SELECT 2"},
+	}, {
+		name:   "unterminated literal",
+		sql:    "SELECT 'a; SELECT 2",
+		expect: []string{"SELECT 'a; SELECT 2"},
+	}, {
+		name:    "backslash escapes of MySQL",
+		dialect: DialectorMySQL,
+		sql:     `SELECT 'it\'s;', ` + "`a;b`" + ` FROM t # hash; comment` + "\n; SELECT 2",
+		expect:  []string{`SELECT 'it\'s;', ` + "`a;b`" + ` FROM t # hash; comment`, "SELECT 2"},
+	}, {
+		name:   "no backslash escapes by default",
+		sql:    `SELECT 'C:\'; SELECT 2`,
+		expect: []string{`SELECT 'C:\'`, "SELECT 2"},
+	}, {
+		name:    "delimiter of MySQL",
+		dialect: DialectorMySQL,
+		sql: "DELIMITER //\nCREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END//\n" +
+			"DELIMITER ;\nCALL p();",
+		expect: []string{"CREATE PROCEDURE p() BEGIN SELECT 1; SELECT 2; END", "CALL p()"},
+	}, {
+		name:    "dollar-quoted body of PostgreSQL",
+		dialect: DialectorPostgres,
+		sql: "CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql;\n" +
+			"SELECT $tag$a;$$;b$tag$, $1; SELECT 2",
+		expect: []string{
+			"CREATE FUNCTION f() RETURNS int AS $$ BEGIN RETURN 1; END; $$ LANGUAGE plpgsql",
+			"SELECT $tag$a;$$;b$tag$, $1",
+			"SELECT 2",
+		},
+	}, {
+		name:    "dollar in identifiers of PostgreSQL",
+		dialect: DialectorPostgres,
+		sql:     "SELECT a$b$ FROM t; SELECT 2",
+		expect:  []string{"SELECT a$b$ FROM t", "SELECT 2"},
+	}, {
+		name:    "brackets of SQL Server",
+		dialect: DialectorSQLServer,
+		sql:     "SELECT [a;]]b] FROM t; SELECT 2",
+		expect:  []string{"SELECT [a;]]b] FROM t", "SELECT 2"},
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expect, splitStatements(tt.sql, tt.dialect))
+		})
+	}
+}
@@ -0,0 +1,214 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	puregosqlite "github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+const (
+	propJournalMode = "journalMode"
+	propBusyTimeout = "busyTimeout"
+)
+
+const (
+	// SQLiteMemory is the store URL of the in-memory SQLite database
+	SQLiteMemory = ":memory:"
+	// driverSQLitePureGo is the SQLite driver which does not require CGO
+	driverSQLitePureGo = "sqlite-purego"
+)
+
+var sqliteJournalModes = []string{"DELETE", "TRUNCATE", "PERSIST", "MEMORY", "WAL", "OFF"}
+
+// sqliteOptions represents the settings of a SQLite store.
+// The in-memory database is shared by all the connections of the pool only,
+// and it is dropped once the last connection is closed, so one connection
+// is held open for the life of the pool, see holdMemoryConn.
+type sqliteOptions struct {
+	Path        string
+	Memory      bool
+	ReadOnly    bool
+	JournalMode string
+	BusyTimeout time.Duration
+}
+
+// parseSQLiteOptions resolves the database file from the store URL:
+// empty means <database>.db in the working directory, a directory means
+// <database>.db in it, and other values are treated as the file path.
+func parseSQLiteOptions(address, database string, properties map[string]string) (opts sqliteOptions, err error) {
+	if opts.ReadOnly, err = parseBoolProperty(properties, propReadOnly); err != nil {
+		return
+	}
+	if opts.BusyTimeout, err = parseDurationProperty(properties, propBusyTimeout); err != nil {
+		return
+	}
+	if v, ok := getProperty(properties, propJournalMode); ok && v != "" {
+		opts.JournalMode = strings.ToUpper(v)
+		if !slices.Contains(sqliteJournalModes, opts.JournalMode) {
+			err = fmt.Errorf("invalid %s %q, should be one of %s", propJournalMode, v,
+				strings.Join(sqliteJournalModes, "/"))
+			return
+		}
+	}
+
+	switch {
+	case address == SQLiteMemory:
+		if opts.ReadOnly {
+			err = errors.New("the in-memory SQLite database cannot be read-only")
+			return
+		}
+		opts.Memory = true
+		opts.Path = database
+		if opts.Path == "" {
+			opts.Path = "atest"
+		}
+	case address == "":
+		opts.Path = fmt.Sprintf("%s.db", database)
+	default:
+		opts.Path = address
+		if info, statErr := os.Stat(address); statErr == nil && info.IsDir() {
+			opts.Path = filepath.Join(address, fmt.Sprintf("%s.db", database))
+		}
+	}
+	return
+}
+
+// isPureGoSQLite reports whether the driver uses the pure-Go SQLite implementation.
+// The driver sqlite is pure-Go as well when building without CGO or with the tag purego.
+func isPureGoSQLite(driver string) bool {
+	return driver == driverSQLitePureGo || sqlitePureGo
+}
+
+func openSQLite(driver, dsn string) gorm.Dialector {
+	if driver == driverSQLitePureGo {
+		return openPureGoSQLite(dsn)
+	}
+	return openDefaultSQLite(dsn)
+}
+
+func openPureGoSQLite(dsn string) gorm.Dialector {
+	return puregosqlite.Open(dsn)
+}
+
+// dsn renders the DSN of the mattn/go-sqlite3 driver, or the glebarez/go-sqlite
+// driver which sets the pragmas by _pragma.
+func (o sqliteOptions) dsn(params url.Values, pureGo bool) string {
+	query := url.Values{}
+	busyTimeout := strconv.FormatInt(o.BusyTimeout.Milliseconds(), 10)
+	if pureGo {
+		if o.JournalMode != "" {
+			query.Add("_pragma", fmt.Sprintf("journal_mode(%s)", o.JournalMode))
+		}
+		if o.BusyTimeout > 0 {
+			query.Add("_pragma", fmt.Sprintf("busy_timeout(%s)", busyTimeout))
+		}
+		if o.ReadOnly {
+			query.Add("_pragma", "query_only(1)")
+		}
+	} else {
+		if o.JournalMode != "" {
+			query.Set("_journal_mode", o.JournalMode)
+		}
+		if o.BusyTimeout > 0 {
+			query.Set("_busy_timeout", busyTimeout)
+		}
+		if o.ReadOnly {
+			query.Set("_query_only", "1")
+		}
+	}
+
+	// the mode and cache parameters only work with the URI filename
+	path := o.Path
+	switch {
+	case o.Memory:
+		// the name is unique in the process, otherwise the pools of the same database share the data
+		path = fmt.Sprintf("file:%s-%d", path, memoryDatabases.Add(1))
+		query.Set("mode", "memory")
+		query.Set("cache", "shared")
+	case o.ReadOnly:
+		path = "file:" + path
+		query.Set("mode", "ro")
+	}
+	return withQuery(path, mergeParams(query, params))
+}
+
+// prepare creates the parent directory of the database file
+func (o sqliteOptions) prepare() (err error) {
+	if o.Memory || o.ReadOnly {
+		return
+	}
+	if dir := filepath.Dir(o.Path); dir != "." {
+		err = os.MkdirAll(dir, 0o755)
+	}
+	return
+}
+
+// memoryDatabases numbers the in-memory databases
+var memoryDatabases atomic.Uint64
+
+// memoryConns holds one connection of each in-memory SQLite pool, by its *sql.DB
+var memoryConns sync.Map
+
+// holdMemoryConn keeps one connection of the in-memory database open until the pool is closed,
+// otherwise the database is dropped once the pool closed all its connections,
+// e.g. because of connMaxLifetime or connMaxIdleTime
+func holdMemoryConn(db *gorm.DB) (err error) {
+	var sqlDB *sql.DB
+	if sqlDB, err = db.DB(); err != nil {
+		return
+	}
+	var conn *sql.Conn
+	if conn, err = sqlDB.Conn(context.Background()); err != nil {
+		err = fmt.Errorf("failed to hold the connection of the in-memory database: %v", err)
+		return
+	}
+	memoryConns.Store(sqlDB, conn)
+	return
+}
+
+// releaseMemoryConn closes the connection held by holdMemoryConn, if any
+func releaseMemoryConn(sqlDB *sql.DB) {
+	if conn, ok := memoryConns.LoadAndDelete(sqlDB); ok {
+		if err := conn.(*sql.Conn).Close(); err != nil {
+			log.Printf("failed to release the connection of the in-memory database: %v", err)
+		}
+	}
+}
+
+func parseBoolProperty(properties map[string]string, key string) (val bool, err error) {
+	if v, ok := getProperty(properties, key); ok && v != "" {
+		if val, err = strconv.ParseBool(v); err != nil {
+			err = fmt.Errorf("failed to parse %s: %v", key, err)
+		}
+	}
+	return
+}
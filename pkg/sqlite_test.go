@@ -0,0 +1,243 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"net/url"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/linuxsuren/api-testing/pkg/server"
+	atest "github.com/linuxsuren/api-testing/pkg/testing"
+	"github.com/linuxsuren/api-testing/pkg/testing/remote"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseSQLiteOptions(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name       string
+		address    string
+		properties map[string]string
+		expect     sqliteOptions
+		dsn        string
+		pureGoDSN  string
+		hasErr     bool
+	}{{
+		name:      "working directory",
+		expect:    sqliteOptions{Path: "atest.db"},
+		dsn:       "atest.db",
+		pureGoDSN: "atest.db",
+	}, {
+		name:      "file path",
+		address:   "/var/lib/atest/store.db",
+		expect:    sqliteOptions{Path: "/var/lib/atest/store.db"},
+		dsn:       "/var/lib/atest/store.db",
+		pureGoDSN: "/var/lib/atest/store.db",
+	}, {
+		name:      "directory",
+		address:   dir,
+		expect:    sqliteOptions{Path: filepath.Join(dir, "atest.db")},
+		dsn:       filepath.Join(dir, "atest.db"),
+		pureGoDSN: filepath.Join(dir, "atest.db"),
+	}, {
+		name:      "in-memory",
+		address:   SQLiteMemory,
+		expect:    sqliteOptions{Path: "atest", Memory: true},
+		dsn:       `^file:atest-\d+\?cache=shared&mode=memory$`,
+		pureGoDSN: `^file:atest-\d+\?cache=shared&mode=memory$`,
+	}, {
+		name:    "read-only with WAL and busy timeout",
+		address: "store.db",
+		properties: map[string]string{
+			propReadOnly:    "true",
+			propJournalMode: "wal",
+			propBusyTimeout: "5s",
+		},
+		expect:    sqliteOptions{Path: "store.db", ReadOnly: true, JournalMode: "WAL", BusyTimeout: 5 * time.Second},
+		dsn:       "file:store.db?_busy_timeout=5000&_journal_mode=WAL&_query_only=1&mode=ro",
+		pureGoDSN: "file:store.db?_pragma=journal_mode%28WAL%29&_pragma=busy_timeout%285000%29&_pragma=query_only%281%29&mode=ro",
+	}, {
+		name:       "read-only in-memory",
+		address:    SQLiteMemory,
+		properties: map[string]string{propReadOnly: "true"},
+		hasErr:     true,
+	}, {
+		name:       "invalid journal mode",
+		properties: map[string]string{propJournalMode: "fake"},
+		hasErr:     true,
+	}, {
+		name:       "invalid busy timeout",
+		properties: map[string]string{propBusyTimeout: "5"},
+		hasErr:     true,
+	}, {
+		name:       "invalid read-only",
+		properties: map[string]string{propReadOnly: "fake"},
+		hasErr:     true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts, err := parseSQLiteOptions(tt.address, "atest", tt.properties)
+			if tt.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, opts)
+				if opts.Memory {
+					// every pool has its own in-memory database
+					assert.Regexp(t, tt.dsn, opts.dsn(url.Values{}, false))
+					assert.NotEqual(t, opts.dsn(url.Values{}, true), opts.dsn(url.Values{}, true))
+				} else {
+					assert.Equal(t, tt.dsn, opts.dsn(url.Values{}, false))
+					assert.Equal(t, tt.pureGoDSN, opts.dsn(url.Values{}, true))
+				}
+			}
+		})
+	}
+}
+
+func TestSQLiteStore(t *testing.T) {
+	for _, driver := range []string{"sqlite", driverSQLitePureGo} {
+		t.Run(driver, func(t *testing.T) {
+			testSQLiteStore(t, driver)
+		})
+	}
+}
+
+func testSQLiteStore(t *testing.T, driver string) {
+	remoteServer := NewRemoteServer(10, "")
+	withStore := func(address string, properties map[string]string) context.Context {
+		properties["driver"] = driver
+		if _, ok := properties["database"]; !ok {
+			properties["database"] = driver
+		}
+		return remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
+			Name:       t.Name(),
+			URL:        address,
+			Properties: properties,
+		})
+	}
+
+	t.Run("in-memory", func(t *testing.T) {
+		ctx := withStore(SQLiteMemory, map[string]string{propMaxOpenConns: "4"})
+		_, err := remoteServer.CreateTestSuite(ctx, &remote.TestSuite{Name: "memory"})
+		assert.NoError(t, err)
+
+		db, release, err := remoteServer.(*dbserver).getClient(ctx)
+		assert.NoError(t, err)
+		defer release()
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+
+		// every connection of the pool sees the same data
+		conns := make([]interface{ Close() error }, 0, 3)
+		for i := 0; i < 3; i++ {
+			conn, err := sqlDB.Conn(context.TODO())
+			assert.NoError(t, err)
+			var count int
+			assert.NoError(t, conn.QueryRowContext(context.TODO(), "SELECT COUNT(*) FROM test_suites").Scan(&count))
+			assert.Equal(t, 1, count)
+			conns = append(conns, conn)
+		}
+		for _, conn := range conns {
+			assert.NoError(t, conn.Close())
+		}
+	})
+
+	t.Run("in-memory stores with the same database", func(t *testing.T) {
+		newContext := func(name string) context.Context {
+			return remote.WithIncomingStoreContext(context.TODO(), &atest.Store{
+				Name:       t.Name() + name,
+				URL:        SQLiteMemory,
+				Properties: map[string]string{"driver": driver},
+			})
+		}
+		first, second := newContext("first"), newContext("second")
+		_, err := remoteServer.CreateTestSuite(first, &remote.TestSuite{Name: "first"})
+		assert.NoError(t, err)
+		_, err = remoteServer.CreateTestSuite(second, &remote.TestSuite{Name: "second"})
+		assert.NoError(t, err)
+
+		// the data of a store stays in its own pool
+		for ctx, name := range map[context.Context]string{first: "first", second: "second"} {
+			suites, err := remoteServer.ListTestSuite(ctx, &server.Empty{})
+			assert.NoError(t, err)
+			if assert.Len(t, suites.Data, 1) {
+				assert.Equal(t, name, suites.Data[0].Name)
+			}
+		}
+	})
+
+	t.Run("in-memory with the connection lifetime", func(t *testing.T) {
+		ctx := withStore(SQLiteMemory, map[string]string{
+			"database":          driver + "-lifetime",
+			propMaxOpenConns:    "1",
+			propConnMaxLifetime: "10ms",
+			propConnMaxIdleTime: "10ms",
+		})
+		_, err := remoteServer.CreateTestSuite(ctx, &remote.TestSuite{Name: "lifetime"})
+		assert.NoError(t, err)
+
+		db, release, err := remoteServer.(*dbserver).getClient(ctx)
+		assert.NoError(t, err)
+		defer release()
+		sqlDB, err := db.DB()
+		assert.NoError(t, err)
+
+		// the pool closes its expired connections, but the data survives
+		time.Sleep(50 * time.Millisecond)
+		sqlDB.SetMaxIdleConns(0)
+		suites, err := remoteServer.ListTestSuite(ctx, &server.Empty{})
+		assert.NoError(t, err)
+		assert.Len(t, suites.Data, 1)
+		assert.Positive(t, sqlDB.Stats().MaxLifetimeClosed+sqlDB.Stats().MaxIdleTimeClosed+sqlDB.Stats().MaxIdleClosed)
+	})
+
+	t.Run("file path", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "nested", "store.db")
+		ctx := withStore(path, map[string]string{
+			propJournalMode: "WAL",
+			propBusyTimeout: "3s",
+		})
+		_, err := remoteServer.CreateTestSuite(ctx, &remote.TestSuite{Name: "file"})
+		assert.NoError(t, err)
+		assert.FileExists(t, path)
+
+		db, release, err := remoteServer.(*dbserver).getClient(ctx)
+		assert.NoError(t, err)
+		defer release()
+		var journalMode string
+		assert.NoError(t, db.Raw("PRAGMA journal_mode").Scan(&journalMode).Error)
+		assert.Equal(t, "wal", journalMode)
+
+		ctx = withStore(path, map[string]string{propReadOnly: "true"})
+		suites, err := remoteServer.ListTestSuite(ctx, &server.Empty{})
+		assert.NoError(t, err)
+		assert.Len(t, suites.Data, 1)
+
+		_, err = remoteServer.CreateTestSuite(ctx, &remote.TestSuite{Name: "readonly"})
+		assert.Error(t, err)
+	})
+
+	t.Run("read-only without the file", func(t *testing.T) {
+		ctx := withStore(filepath.Join(t.TempDir(), "missing.db"), map[string]string{propReadOnly: "true"})
+		_, err := remoteServer.ListTestSuite(ctx, &server.Empty{})
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,132 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+
+	"github.com/linuxsuren/api-testing/pkg/server"
+	"gorm.io/gorm"
+)
+
+// sqlServerDSN generates the URL style DSN of go-mssqldb. The login packet is encrypted
+// by default, the sslMode require/verify-ca/verify-full encrypts the whole connection.
+// The host name is always verified unless the mode is require.
+func sqlServerDSN(user, password, address, database string, tlsOpts tlsOptions,
+	params url.Values) (dsn string, err error) {
+	if !strings.Contains(address, ":") {
+		address = fmt.Sprintf("%s:%d", address, 1433)
+	}
+
+	query := url.Values{}
+	if database != "" {
+		query.Set("database", database)
+	}
+	if tlsOpts.enabled() {
+		query.Set("encrypt", "true")
+		query.Set("TrustServerCertificate", fmt.Sprint(tlsOpts.Mode == SSLModeRequire))
+	}
+	if tlsOpts.RootCert != "" {
+		if strings.Contains(tlsOpts.RootCert, "-----BEGIN") {
+			err = fmt.Errorf("%s must be a file path for sqlserver", propSSLRootCert)
+			return
+		}
+		query.Set("certificate", tlsOpts.RootCert)
+	}
+	if tlsOpts.ServerName != "" {
+		query.Set("hostNameInCertificate", tlsOpts.ServerName)
+	}
+	if tlsOpts.Cert != "" {
+		err = fmt.Errorf("%s is not supported by sqlserver", propSSLCert)
+		return
+	}
+
+	dsn = (&url.URL{
+		Scheme:   "sqlserver",
+		User:     url.UserPassword(user, password),
+		Host:     address,
+		RawQuery: mergeParams(query, params).Encode(),
+	}).String()
+	return
+}
+
+type sqlServerDataQuery struct {
+	*commonDataQuery
+}
+
+// GetLabels returns the estimated plan of the query via SHOWPLAN_ALL, and the server version
+func (q *sqlServerDataQuery) GetLabels(ctx context.Context, sql string, args ...interface{}) (metadata []*server.Pair) {
+	metadata = make([]*server.Pair, 0)
+	if !strings.Contains(sql, ";") {
+		metadata = append(metadata, q.showPlan(ctx, sql, args...)...)
+	}
+
+	var version string
+	if err := q.db.WithContext(ctx).Raw("SELECT @@VERSION").Row().Scan(&version); err == nil {
+		// only keep the first line, e.g. Microsoft SQL Server 2022 (RTM) - 16.0.1000.6 (X64)
+		metadata = append(metadata, &server.Pair{
+			Key:   "version",
+			Value: strings.TrimSpace(strings.Split(version, "\n")[0]),
+		})
+	}
+	return
+}
+
+func (q *sqlServerDataQuery) showPlan(ctx context.Context, sql string, args ...interface{}) (metadata []*server.Pair) {
+	// SHOWPLAN_ALL is a session option, so the statements must share one connection
+	err := q.db.WithContext(ctx).Connection(func(tx *gorm.DB) (err error) {
+		if err = tx.Exec("SET SHOWPLAN_ALL ON").Error; err != nil {
+			return
+		}
+		defer func() {
+			if offErr := tx.Exec("SET SHOWPLAN_ALL OFF").Error; offErr != nil && err == nil {
+				err = offErr
+			}
+		}()
+
+		var result *server.DataQueryResult
+		if result, err = sqlQuery(ctx, sql, tx, args...); err == nil && len(result.Items) > 0 {
+			for _, data := range result.Items[0].Data {
+				switch data.Key {
+				case "Type":
+					metadata = append(metadata, &server.Pair{
+						Key:   "sql_type",
+						Value: data.Value,
+					})
+				case "EstimateRows":
+					metadata = append(metadata, &server.Pair{
+						Key:   "estimate_rows",
+						Value: data.Value,
+					})
+				case "TotalSubtreeCost":
+					metadata = append(metadata, &server.Pair{
+						Key:   "sql_cost",
+						Value: data.Value,
+					})
+				}
+			}
+		}
+		return
+	})
+	if err != nil {
+		log.Printf("failed to get the plan of SQL Server: %v", err)
+	}
+	return
+}
@@ -0,0 +1,186 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/linuxsuren/api-testing/pkg/server"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	labelSuperTables = "_super_tables"
+	labelChildTables = "_child_tables"
+)
+
+// isTDengine reports whether the store is TDengine
+func isTDengine(db *gorm.DB) bool {
+	return db.Dialector.Name() == DialectorTDengine
+}
+
+// deleteByTimestamp deletes the rows of the identity in TDengine, which can only delete the rows
+// by the leading timestamp column, so the timestamps of the rows are looked up first
+func deleteByTimestamp(identity *gorm.DB, model interface{}) (err error) {
+	var timestamps []time.Time
+	if err = identity.Pluck(tdengineTimestampColumn, &timestamps).Error; err != nil {
+		return
+	}
+	for _, ts := range timestamps {
+		if err = identity.Session(&gorm.Session{NewDB: true}).
+			Where(clause.Eq{Column: clause.Column{Name: tdengineTimestampColumn}, Value: ts}).
+			Delete(model).Error; err != nil {
+			return
+		}
+	}
+	return
+}
+
+// updateByTimestamp updates the rows of the identity in TDengine, which cannot update the rows.
+// The rows are read along with their timestamps, and inserted again with the changed values,
+// the row with the same timestamp overwrites the existing one. Like Updates, only the non-zero
+// fields of a struct are changed, while all the values of a map are.
+func updateByTimestamp(identity *gorm.DB, values interface{}) (err error) {
+	var changes map[string]interface{}
+	if changes, err = columnValues(identity, values); err != nil {
+		return
+	}
+
+	var rows []map[string]interface{}
+	if err = identity.Find(&rows).Error; err != nil {
+		return
+	}
+	for _, row := range rows {
+		for column, value := range changes {
+			row[column] = value
+		}
+		if err = identity.Session(&gorm.Session{NewDB: true}).Model(identity.Statement.Model).
+			Create(row).Error; err != nil {
+			return
+		}
+	}
+	return
+}
+
+// columnValues returns the values by the column names, only the non-zero fields of a struct are returned
+func columnValues(db *gorm.DB, values interface{}) (columns map[string]interface{}, err error) {
+	if m, ok := values.(map[string]interface{}); ok {
+		columns = m
+		return
+	}
+
+	stmt := &gorm.Statement{DB: db}
+	if err = stmt.Parse(values); err != nil {
+		return
+	}
+	value := reflect.Indirect(reflect.ValueOf(values))
+	columns = make(map[string]interface{}, len(stmt.Schema.DBNames))
+	for _, dbName := range stmt.Schema.DBNames {
+		if fieldValue, isZero := stmt.Schema.FieldsByDBName[dbName].ValueOf(db.Statement.Context, value); !isZero {
+			columns[dbName] = fieldValue
+		}
+	}
+	return
+}
+
+// tdengineDataQuery lists the super tables, child tables and normal tables of TDengine
+type tdengineDataQuery struct {
+	*commonDataQuery
+}
+
+var _ TableKindsQuery = &tdengineDataQuery{}
+
+func (q *tdengineDataQuery) GetTables(ctx context.Context, currentDatabase string) (tables []string, err error) {
+	var superTables, otherTables []string
+	if superTables, err = q.showTables(ctx, "STABLES", currentDatabase); err != nil {
+		return
+	}
+	if otherTables, err = q.showTables(ctx, "TABLES", currentDatabase); err != nil {
+		return
+	}
+
+	tables = append(superTables, otherTables...)
+	sort.Strings(tables)
+	return
+}
+
+// GetTableKinds returns the super tables, and the super table of each child table
+func (q *tdengineDataQuery) GetTableKinds(ctx context.Context, currentDatabase string) (labels []*server.Pair, err error) {
+	var superTables []string
+	if superTables, err = q.showTables(ctx, "STABLES", currentDatabase); err != nil {
+		return
+	}
+	sort.Strings(superTables)
+
+	var children []struct {
+		TableName  string
+		StableName string
+	}
+	if err = q.db.WithContext(ctx).Raw(fmt.Sprintf("SELECT table_name, stable_name FROM information_schema.ins_tables "+
+		"WHERE db_name = %s AND type = 'CHILD_TABLE'", quoteTDengineString(currentDatabase))).Scan(&children).Error; err != nil {
+		return
+	}
+	childTables := make(map[string]string, len(children))
+	for _, child := range children {
+		childTables[child.TableName] = child.StableName
+	}
+
+	var data []byte
+	if data, err = json.Marshal(superTables); err != nil {
+		return
+	}
+	labels = append(labels, &server.Pair{Key: labelSuperTables, Value: string(data)})
+	if data, err = json.Marshal(childTables); err != nil {
+		return
+	}
+	labels = append(labels, &server.Pair{Key: labelChildTables, Value: string(data)})
+	return
+}
+
+// GetLabels returns the server version, TDengine does not have the variable version
+func (q *tdengineDataQuery) GetLabels(ctx context.Context, _ string, _ ...interface{}) (metadata []*server.Pair) {
+	metadata = make([]*server.Pair, 0)
+
+	var version string
+	if err := q.db.WithContext(ctx).Raw("SELECT SERVER_VERSION()").Row().Scan(&version); err == nil {
+		metadata = append(metadata, &server.Pair{
+			Key:   "version",
+			Value: version,
+		})
+	}
+	return
+}
+
+func (q *tdengineDataQuery) showTables(ctx context.Context, kind, database string) (tables []string, err error) {
+	query := "SHOW " + kind
+	if database != "" {
+		query = fmt.Sprintf("SHOW `%s`.%s", database, kind)
+	}
+	err = q.db.WithContext(ctx).Raw(query).Scan(&tables).Error
+	return
+}
+
+func quoteTDengineString(val string) string {
+	val = strings.ReplaceAll(val, `\`, `\\`)
+	return "'" + strings.ReplaceAll(val, "'", `\'`) + "'"
+}
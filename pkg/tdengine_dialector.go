@@ -16,38 +16,74 @@ limitations under the License.
 package pkg
 
 import (
+	"context"
 	"database/sql"
+	"database/sql/driver"
 	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
 
 	_ "github.com/taosdata/driver-go/v3/taosWS"
-	"gorm.io/driver/mysql"
 	"gorm.io/gorm"
+	"gorm.io/gorm/callbacks"
 	"gorm.io/gorm/clause"
 	"gorm.io/gorm/logger"
+	"gorm.io/gorm/migrator"
 	"gorm.io/gorm/schema"
 )
 
 var _ gorm.Dialector = &tdengineDialector{}
 
+// tdengineTimestampColumn is the leading timestamp column of the store tables,
+// every TDengine table has to start with a TIMESTAMP column as the primary key
+const tdengineTimestampColumn = "ts"
+
+// tdengineLongColumns are the columns of the request and response payloads, which are larger than the other
+// strings. The sizes of all the columns of a table are within the row size limit 64KB of TDengine 3.0.5+.
+var tdengineLongColumns = []string{"body", "expect_body", "expect_schema", "output"}
+
+const (
+	tdengineStringSize   = 1024
+	tdengineLongTextSize = 8192
+)
+
 type tdengineDialector struct {
 	DSN string
 }
 
 func (d tdengineDialector) Name() string {
-	return "tdengine"
+	return DialectorTDengine
 }
 
 func (d tdengineDialector) Initialize(db *gorm.DB) (err error) {
 	// Initialize the TDengine connection here
 	if db.ConnPool == nil {
-		db.ConnPool, err = sql.Open("taosWS", d.DSN)
+		if db.ConnPool, err = sql.Open("taosWS", d.DSN); err != nil {
+			return
+		}
 	}
+	if sqlDB, ok := db.ConnPool.(*sql.DB); ok {
+		db.ConnPool = &tdengineConnPool{db: sqlDB}
+	}
+	// the raw queries are executed by the row and query callbacks
+	callbacks.RegisterDefaultCallbacks(db, &callbacks.Config{})
+	db.ClauseBuilders["VALUES"] = buildTDengineValues
 	return
 }
 
 func (d tdengineDialector) Migrator(db *gorm.DB) gorm.Migrator {
-	// Return the TDengine migrator here
-	return &mysql.Migrator{}
+	return tdengineMigrator{
+		Migrator: migrator.Migrator{
+			Config: migrator.Config{
+				DB:        db,
+				Dialector: d,
+			},
+		},
+	}
 }
 
 func (d tdengineDialector) DataTypeOf(field *schema.Field) string {
@@ -72,11 +108,15 @@ func (d tdengineDialector) DataTypeOf(field *schema.Field) string {
 		}
 		return "double"
 	case schema.String:
-		size := field.Size
-		if size == 0 {
-			size = 64
+		if field.Size == 0 {
+			// the NCHAR takes 4 bytes per character, which exceeds the row size limit for the long text
+			size := tdengineStringSize
+			if slices.Contains(tdengineLongColumns, field.DBName) {
+				size = tdengineLongTextSize
+			}
+			return fmt.Sprintf("VARCHAR(%d)", size)
 		}
-		return fmt.Sprintf("NCHAR(%d)", size)
+		return fmt.Sprintf("NCHAR(%d)", field.Size)
 	case schema.Time:
 		return "TIMESTAMP"
 	case schema.Bytes:
@@ -94,19 +134,20 @@ func (d tdengineDialector) DefaultValueOf(field *schema.Field) clause.Expression
 	return clause.Expr{SQL: "NULL"}
 }
 
+// BindVarTo writes the placeholder, which is interpolated by tdengineConnPool
 func (d tdengineDialector) BindVarTo(writer clause.Writer, stmt *gorm.Statement, v interface{}) {
-	// Bind variables for TDengine
-	switch v.(type) {
-	case string:
-		writer.WriteString("'?'")
-	default:
-		writer.WriteByte('?')
-	}
+	writer.WriteByte('?')
 }
 
 func (d tdengineDialector) QuoteTo(writer clause.Writer, str string) {
-	// Quote identifiers for TDengine
-	writer.WriteString(str)
+	for i, name := range strings.Split(str, ".") {
+		if i > 0 {
+			writer.WriteByte('.')
+		}
+		writer.WriteByte('`')
+		writer.WriteString(name)
+		writer.WriteByte('`')
+	}
 }
 
 func (d tdengineDialector) Explain(sql string, vars ...interface{}) string {
@@ -117,3 +158,194 @@ func (d tdengineDialector) Explain(sql string, vars ...interface{}) string {
 func NewTDengineDialector(dsn string) gorm.Dialector {
 	return tdengineDialector{DSN: dsn}
 }
+
+// buildTDengineValues fills the leading timestamp column of the store tables,
+// or moves it to the front when the rows are inserted with their timestamps
+func buildTDengineValues(c clause.Clause, builder clause.Builder) {
+	if values, ok := c.Expression.(clause.Values); ok && len(values.Columns) > 0 {
+		if stmt, ok := builder.(*gorm.Statement); ok && stmt.Schema != nil &&
+			stmt.Schema.LookUpField(tdengineTimestampColumn) == nil {
+			index := slices.IndexFunc(values.Columns, func(column clause.Column) bool {
+				return column.Name == tdengineTimestampColumn
+			})
+			rows := make([][]interface{}, len(values.Values))
+			if index < 0 {
+				values.Columns = append([]clause.Column{{Name: tdengineTimestampColumn}}, values.Columns...)
+				for i, row := range values.Values {
+					rows[i] = append([]interface{}{nextTDengineTimestamp()}, row...)
+				}
+			} else {
+				values.Columns = slices.Concat(values.Columns[index:index+1], values.Columns[:index], values.Columns[index+1:])
+				for i, row := range values.Values {
+					rows[i] = slices.Concat(row[index:index+1], row[:index], row[index+1:])
+				}
+			}
+			values.Values = rows
+			c.Expression = values
+		}
+	}
+	c.Build(builder)
+}
+
+var tdengineClock struct {
+	sync.Mutex
+	last time.Time
+}
+
+// nextTDengineTimestamp returns the increasing timestamps in milliseconds,
+// the rows with the same timestamp overwrite each other in TDengine
+func nextTDengineTimestamp() time.Time {
+	tdengineClock.Lock()
+	defer tdengineClock.Unlock()
+
+	now := time.Now().UTC().Truncate(time.Millisecond)
+	if !now.After(tdengineClock.last) {
+		now = tdengineClock.last.Add(time.Millisecond)
+	}
+	tdengineClock.last = now
+	return now
+}
+
+// tdengineConnPool interpolates the arguments before sending the statements,
+// because the TDengine driver neither quotes nor escapes the strings
+type tdengineConnPool struct {
+	db *sql.DB
+}
+
+var _ gorm.ConnPoolBeginner = &tdengineConnPool{}
+
+func (p *tdengineConnPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return p.db.PrepareContext(ctx, query)
+}
+
+func (p *tdengineConnPool) ExecContext(ctx context.Context, query string, args ...interface{}) (result sql.Result, err error) {
+	if query, err = interpolateTDengineParams(query, args); err == nil {
+		result, err = p.db.ExecContext(ctx, query)
+	}
+	return
+}
+
+func (p *tdengineConnPool) QueryContext(ctx context.Context, query string, args ...interface{}) (rows *sql.Rows, err error) {
+	if query, err = interpolateTDengineParams(query, args); err == nil {
+		rows, err = p.db.QueryContext(ctx, query)
+	}
+	return
+}
+
+func (p *tdengineConnPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	interpolated, err := interpolateTDengineParams(query, args)
+	if err != nil {
+		// the driver reports the error of the arguments
+		return p.db.QueryRowContext(ctx, query, args...)
+	}
+	return p.db.QueryRowContext(ctx, interpolated)
+}
+
+func (p *tdengineConnPool) Ping() error {
+	return p.db.Ping()
+}
+
+func (p *tdengineConnPool) GetDBConn() (*sql.DB, error) {
+	return p.db, nil
+}
+
+// BeginTx returns a pseudo transaction, TDengine does not support transactions
+// and the statements take effect immediately
+func (p *tdengineConnPool) BeginTx(_ context.Context, _ *sql.TxOptions) (gorm.ConnPool, error) {
+	return &tdengineTx{tdengineConnPool: p}, nil
+}
+
+type tdengineTx struct {
+	*tdengineConnPool
+}
+
+func (*tdengineTx) Commit() error {
+	return nil
+}
+
+func (*tdengineTx) Rollback() error {
+	return nil
+}
+
+// interpolateTDengineParams replaces the placeholders, except the ones in the quoted strings and comments,
+// with the literals
+func interpolateTDengineParams(query string, args []interface{}) (string, error) {
+	if len(args) == 0 {
+		return query, nil
+	}
+
+	opts := splitOptionsOf(DialectorTDengine)
+	var builder strings.Builder
+	var index int
+	for i := 0; i < len(query); {
+		if end := skippedEnd(query, i, opts); end > 0 {
+			builder.WriteString(query[i : i+end])
+			i += end
+			continue
+		}
+		if query[i] != '?' {
+			builder.WriteByte(query[i])
+			i++
+			continue
+		}
+
+		if index >= len(args) {
+			return "", fmt.Errorf("not enough arguments for the statement, got %d", len(args))
+		}
+		literal, err := tdengineLiteral(args[index])
+		if err != nil {
+			return "", err
+		}
+		index++
+		builder.WriteString(literal)
+		i++
+	}
+	if index != len(args) {
+		return "", fmt.Errorf("the statement expects %d arguments, got %d", index, len(args))
+	}
+	return builder.String(), nil
+}
+
+func tdengineLiteral(arg interface{}) (literal string, err error) {
+	if valuer, ok := arg.(driver.Valuer); ok {
+		if arg, err = valuer.Value(); err != nil {
+			return
+		}
+	}
+
+	switch v := arg.(type) {
+	case nil:
+		return "NULL", nil
+	case string:
+		return quoteTDengineString(v), nil
+	case []byte:
+		return quoteTDengineString(string(v)), nil
+	case time.Time:
+		return quoteTDengineString(v.Format(time.RFC3339Nano)), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+
+	value := reflect.ValueOf(arg)
+	switch value.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		literal = strconv.FormatInt(value.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		literal = strconv.FormatUint(value.Uint(), 10)
+	case reflect.Float32:
+		literal = strconv.FormatFloat(value.Float(), 'g', -1, 32)
+	case reflect.Float64:
+		literal = strconv.FormatFloat(value.Float(), 'g', -1, 64)
+	case reflect.String:
+		literal = quoteTDengineString(value.String())
+	case reflect.Ptr:
+		if value.IsNil() {
+			literal = "NULL"
+		} else {
+			literal, err = tdengineLiteral(value.Elem().Interface())
+		}
+	default:
+		err = fmt.Errorf("unsupported argument type %T", arg)
+	}
+	return
+}
@@ -0,0 +1,192 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/migrator"
+	"gorm.io/gorm/schema"
+)
+
+// tdengineMigrator creates the normal tables of TDengine, which have no constraints or indexes
+type tdengineMigrator struct {
+	migrator.Migrator
+}
+
+var typeLengthRegexp = regexp.MustCompile(`\((\d+)\)`)
+
+func (m tdengineMigrator) CurrentDatabase() (name string) {
+	m.DB.Raw("SELECT DATABASE()").Row().Scan(&name)
+	return
+}
+
+// FullDataTypeOf returns the data type only, TDengine does not support the column constraints
+func (m tdengineMigrator) FullDataTypeOf(field *schema.Field) clause.Expr {
+	return clause.Expr{SQL: m.DataTypeOf(field)}
+}
+
+func (m tdengineMigrator) GetTables() (tableList []string, err error) {
+	err = m.DB.Raw("SELECT table_name FROM information_schema.ins_tables WHERE db_name = ?", m.CurrentDatabase()).
+		Scan(&tableList).Error
+	return
+}
+
+func (m tdengineMigrator) HasTable(value interface{}) bool {
+	var count int64
+	m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		return m.DB.Raw("SELECT COUNT(*) FROM information_schema.ins_tables WHERE db_name = ? AND table_name = ?",
+			m.CurrentDatabase(), stmt.Table).Row().Scan(&count)
+	})
+	return count > 0
+}
+
+// CreateTable creates the table with the leading timestamp column if the model does not have it
+func (m tdengineMigrator) CreateTable(values ...interface{}) error {
+	for _, value := range m.ReorderModels(values, false) {
+		if err := m.RunWithValue(value, func(stmt *gorm.Statement) error {
+			columns := make([]string, 0, len(stmt.Schema.DBNames)+1)
+			args := []interface{}{m.CurrentTable(stmt)}
+			if stmt.Schema.LookUpField(tdengineTimestampColumn) == nil {
+				columns = append(columns, "? TIMESTAMP")
+				args = append(args, clause.Column{Name: tdengineTimestampColumn})
+			}
+			for _, dbName := range stmt.Schema.DBNames {
+				field := stmt.Schema.FieldsByDBName[dbName]
+				if field.IgnoreMigration {
+					continue
+				}
+				columns = append(columns, "? ?")
+				args = append(args, clause.Column{Name: dbName}, m.FullDataTypeOf(field))
+			}
+			return m.DB.Exec(fmt.Sprintf("CREATE TABLE IF NOT EXISTS ? (%s)", strings.Join(columns, ", ")), args...).Error
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m tdengineMigrator) AddColumn(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		field := stmt.Schema.LookUpField(name)
+		if field == nil {
+			return fmt.Errorf("failed to look up field with name: %s", name)
+		}
+		if field.IgnoreMigration {
+			return nil
+		}
+		return m.DB.Exec("ALTER TABLE ? ADD COLUMN ? ?",
+			m.CurrentTable(stmt), clause.Column{Name: field.DBName}, m.FullDataTypeOf(field)).Error
+	})
+}
+
+// AlterColumn changes the length of the column, TDengine is not able to change the other types
+func (m tdengineMigrator) AlterColumn(value interface{}, name string) error {
+	return m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		field := stmt.Schema.LookUpField(name)
+		if field == nil {
+			return fmt.Errorf("failed to look up field with name: %s", name)
+		}
+		return m.DB.Exec("ALTER TABLE ? MODIFY COLUMN ? ?",
+			m.CurrentTable(stmt), clause.Column{Name: field.DBName}, m.FullDataTypeOf(field)).Error
+	})
+}
+
+func (m tdengineMigrator) HasColumn(value interface{}, name string) bool {
+	var count int64
+	m.RunWithValue(value, func(stmt *gorm.Statement) error {
+		if field := stmt.Schema.LookUpField(name); field != nil {
+			name = field.DBName
+		}
+		return m.DB.Raw("SELECT COUNT(*) FROM information_schema.ins_columns WHERE db_name = ? AND table_name = ? AND col_name = ?",
+			m.CurrentDatabase(), stmt.Table, name).Row().Scan(&count)
+	})
+	return count > 0
+}
+
+// MigrateColumn widens the columns which are shorter than the model
+func (m tdengineMigrator) MigrateColumn(value interface{}, field *schema.Field, columnType gorm.ColumnType) error {
+	if field.IgnoreMigration {
+		return nil
+	}
+
+	length, ok := columnType.Length()
+	if matches := typeLengthRegexp.FindStringSubmatch(m.DataTypeOf(field)); ok && len(matches) == 2 {
+		if size, err := strconv.ParseInt(matches[1], 10, 64); err == nil && size > length {
+			return m.AlterColumn(value, field.DBName)
+		}
+	}
+	return nil
+}
+
+// ColumnTypes returns the columns from DESCRIBE, the first one is the primary timestamp column
+func (m tdengineMigrator) ColumnTypes(value interface{}) (columnTypes []gorm.ColumnType, err error) {
+	err = m.RunWithValue(value, func(stmt *gorm.Statement) (err error) {
+		var columns []struct {
+			Field  string
+			Type   string
+			Length int64
+			Note   string
+		}
+		if err = m.DB.Raw("DESCRIBE ?", m.CurrentTable(stmt)).Scan(&columns).Error; err != nil {
+			return
+		}
+
+		for i, column := range columns {
+			fullType := column.Type
+			switch strings.ToUpper(column.Type) {
+			case "VARCHAR", "BINARY", "NCHAR", "VARBINARY", "GEOMETRY":
+				fullType = fmt.Sprintf("%s(%d)", column.Type, column.Length)
+			}
+			columnTypes = append(columnTypes, migrator.ColumnType{
+				NameValue:       sql.NullString{String: column.Field, Valid: true},
+				DataTypeValue:   sql.NullString{String: column.Type, Valid: true},
+				ColumnTypeValue: sql.NullString{String: fullType, Valid: true},
+				LengthValue:     sql.NullInt64{Int64: column.Length, Valid: true},
+				PrimaryKeyValue: sql.NullBool{Bool: i == 0, Valid: true},
+				NullableValue:   sql.NullBool{Bool: i != 0, Valid: true},
+				CommentValue:    sql.NullString{String: column.Note, Valid: column.Note != ""},
+			})
+		}
+		return
+	})
+	return
+}
+
+// HasIndex always returns false, TDengine only indexes the tags of the super tables
+func (m tdengineMigrator) HasIndex(value interface{}, name string) bool {
+	return false
+}
+
+func (m tdengineMigrator) CreateIndex(value interface{}, name string) error {
+	return nil
+}
+
+func (m tdengineMigrator) DropIndex(value interface{}, name string) error {
+	return nil
+}
+
+// CreateConstraint does nothing, TDengine does not support the constraints
+func (m tdengineMigrator) CreateConstraint(value interface{}, name string) error {
+	return nil
+}
@@ -0,0 +1,456 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/linuxsuren/api-testing/pkg/server"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func newMockTDengine(t *testing.T) (DataQuery, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(sqlmock.QueryMatcherEqual))
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	db, err := gorm.Open(NewTDengineDialector(""), &gorm.Config{ConnPool: sqlDB})
+	assert.NoError(t, err)
+	return NewDataQuery(DialectorTDengine, db), mock
+}
+
+func TestTDengineDataQuery(t *testing.T) {
+	ctx := context.TODO()
+
+	t.Run("GetDatabases", func(t *testing.T) {
+		query, mock := newMockTDengine(t)
+		mock.ExpectQuery("SHOW DATABASES").
+			WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("power").AddRow("information_schema"))
+
+		databases, err := query.GetDatabases(ctx)
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"information_schema", "power"}, databases)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetTables", func(t *testing.T) {
+		query, mock := newMockTDengine(t)
+		mock.ExpectQuery("SHOW `power`.STABLES").
+			WillReturnRows(sqlmock.NewRows([]string{"stable_name"}).AddRow("meters"))
+		mock.ExpectQuery("SHOW `power`.TABLES").
+			WillReturnRows(sqlmock.NewRows([]string{"table_name"}).AddRow("d1001").AddRow("alerts"))
+
+		tables, err := query.GetTables(ctx, "power")
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"alerts", "d1001", "meters"}, tables)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetTables without database", func(t *testing.T) {
+		query, mock := newMockTDengine(t)
+		mock.ExpectQuery("SHOW STABLES").WillReturnError(errors.New("database not specified"))
+
+		_, err := query.GetTables(ctx, "")
+		assert.Error(t, err)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetTableKinds", func(t *testing.T) {
+		query, mock := newMockTDengine(t)
+		mock.ExpectQuery("SHOW `power`.STABLES").
+			WillReturnRows(sqlmock.NewRows([]string{"stable_name"}).AddRow("meters").AddRow("devices"))
+		mock.ExpectQuery("SELECT table_name, stable_name FROM information_schema.ins_tables " +
+			"WHERE db_name = 'power' AND type = 'CHILD_TABLE'").
+			WillReturnRows(sqlmock.NewRows([]string{"table_name", "stable_name"}).
+				AddRow("d1001", "meters").AddRow("d1002", "meters"))
+
+		labels, err := query.(TableKindsQuery).GetTableKinds(ctx, "power")
+		assert.NoError(t, err)
+		assert.Equal(t, []*server.Pair{
+			{Key: labelSuperTables, Value: `["devices","meters"]`},
+			{Key: labelChildTables, Value: `{"d1001":"meters","d1002":"meters"}`},
+		}, labels)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetCurrentDatabase", func(t *testing.T) {
+		query, mock := newMockTDengine(t)
+		mock.ExpectQuery("SELECT DATABASE() AS name").
+			WillReturnRows(sqlmock.NewRows([]string{"name"}).AddRow("power"))
+
+		current, err := query.GetCurrentDatabase()
+		assert.NoError(t, err)
+		assert.Equal(t, "power", current)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetLabels", func(t *testing.T) {
+		query, mock := newMockTDengine(t)
+		mock.ExpectQuery("SELECT SERVER_VERSION()").
+			WillReturnRows(sqlmock.NewRows([]string{"server_version()"}).AddRow("3.3.5.0"))
+
+		assert.Equal(t, []*server.Pair{{Key: "version", Value: "3.3.5.0"}},
+			query.GetLabels(ctx, "SELECT * FROM meters"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("GetLabels without version", func(t *testing.T) {
+		query, mock := newMockTDengine(t)
+		mock.ExpectQuery("SELECT SERVER_VERSION()").WillReturnError(errors.New("permission denied"))
+
+		assert.Empty(t, query.GetLabels(ctx, "SELECT 1"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTDengineDialect(t *testing.T) {
+	dialect := GetInnerSQL(DialectorTDengine)
+	tests := []struct {
+		query  string
+		expect string
+	}{{
+		query:  InnerSelectTable_ + "meters",
+		expect: "SELECT * FROM meters",
+	}, {
+		query:  InnerSelectTableLimit_ + "meters",
+		expect: "SELECT * FROM meters LIMIT 100",
+	}, {
+		query:  InnerDescribeTable_ + "meters",
+		expect: "DESCRIBE meters",
+	}, {
+		query:  InnerShowDatabases,
+		expect: "SHOW DATABASES",
+	}, {
+		query:  InnerShowTables,
+		expect: "SHOW `%s`.TABLES",
+	}, {
+		query:  InnerCurrentDB,
+		expect: "SELECT DATABASE() AS name",
+	}}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			assert.Equal(t, tt.expect, dialect.ToNativeSQL(tt.query))
+		})
+	}
+	assert.Equal(t, `'it\'s'`, quoteTDengineString("it's"))
+}
+
+func newMockTDengineDB(t *testing.T, matcher sqlmock.QueryMatcher) (*gorm.DB, sqlmock.Sqlmock) {
+	sqlDB, mock, err := sqlmock.New(sqlmock.QueryMatcherOption(matcher))
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = sqlDB.Close()
+	})
+
+	db, err := gorm.Open(NewTDengineDialector(""), &gorm.Config{ConnPool: sqlDB})
+	assert.NoError(t, err)
+	return db, mock
+}
+
+func TestTDengineMigrator(t *testing.T) {
+	expectHasTable := func(mock sqlmock.Sqlmock, count int) {
+		mock.ExpectQuery("SELECT DATABASE()").WillReturnRows(sqlmock.NewRows([]string{"database()"}).AddRow("atest"))
+		mock.ExpectQuery("SELECT COUNT(*) FROM information_schema.ins_tables WHERE db_name = 'atest' AND table_name = 'test_suites'").
+			WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(count))
+	}
+
+	t.Run("create table", func(t *testing.T) {
+		db, mock := newMockTDengineDB(t, sqlmock.QueryMatcherEqual)
+		expectHasTable(mock, 0)
+		mock.ExpectExec("CREATE TABLE IF NOT EXISTS `test_suites` (`ts` TIMESTAMP, `name` VARCHAR(1024), " +
+			"`api` VARCHAR(1024), `spec_kind` VARCHAR(1024), `spec_url` VARCHAR(1024), `param` VARCHAR(1024))").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, db.AutoMigrate(&TestSuite{}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("migrate columns", func(t *testing.T) {
+		db, mock := newMockTDengineDB(t, sqlmock.QueryMatcherEqual)
+		expectHasTable(mock, 1)
+		mock.ExpectQuery("DESCRIBE `test_suites`").
+			WillReturnRows(sqlmock.NewRows([]string{"field", "type", "length", "note"}).
+				AddRow("ts", "TIMESTAMP", 8, "").
+				AddRow("name", "VARCHAR", 1024, "").
+				AddRow("api", "VARCHAR", 256, "").
+				AddRow("spec_kind", "VARCHAR", 1024, "").
+				AddRow("spec_url", "VARCHAR", 1024, ""))
+		mock.ExpectExec("ALTER TABLE `test_suites` MODIFY COLUMN `api` VARCHAR(1024)").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+		mock.ExpectExec("ALTER TABLE `test_suites` ADD COLUMN `param` VARCHAR(1024)").
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		assert.NoError(t, db.AutoMigrate(&TestSuite{}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("column types", func(t *testing.T) {
+		db, mock := newMockTDengineDB(t, sqlmock.QueryMatcherEqual)
+		mock.ExpectQuery("DESCRIBE `test_suites`").
+			WillReturnRows(sqlmock.NewRows([]string{"field", "type", "length", "note"}).
+				AddRow("ts", "TIMESTAMP", 8, "").
+				AddRow("name", "NCHAR", 200, ""))
+
+		columnTypes, err := db.Migrator().ColumnTypes(&TestSuite{})
+		assert.NoError(t, err)
+		if assert.Len(t, columnTypes, 2) {
+			isPrimaryKey, _ := columnTypes[0].PrimaryKey()
+			assert.True(t, isPrimaryKey)
+			columnType, _ := columnTypes[1].ColumnType()
+			assert.Equal(t, "NCHAR(200)", columnType)
+		}
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("create and find", func(t *testing.T) {
+		db, mock := newMockTDengineDB(t, sqlmock.QueryMatcherRegexp)
+		mock.ExpectExec("^" + regexp.QuoteMeta("INSERT INTO `test_suites` (`ts`,`name`,`api`,`spec_kind`,`spec_url`,`param`) VALUES ('") +
+			`\d{4}-[^']+` + regexp.QuoteMeta(`','it\'s','http://localhost?a=b','','','')`) + "$").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectQuery("^" + regexp.QuoteMeta("SELECT * FROM `test_suites` WHERE name = 'it\\'s'") + "$").
+			WillReturnRows(sqlmock.NewRows([]string{"ts", "name", "api"}).AddRow(time.Now(), "it's", "http://localhost?a=b"))
+
+		assert.NoError(t, db.Create(&TestSuite{Name: "it's", API: "http://localhost?a=b"}).Error)
+		var suites []TestSuite
+		assert.NoError(t, db.Find(&suites, "name = ?", "it's").Error)
+		assert.Equal(t, []TestSuite{{Name: "it's", API: "http://localhost?a=b"}}, suites)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestTDengineSchemaMigrator(t *testing.T) {
+	db, mock := newMockTDengineDB(t, sqlmock.QueryMatcherRegexp)
+	expectHasTable := func(table string) {
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT DATABASE()")).
+			WillReturnRows(sqlmock.NewRows([]string{"database()"}).AddRow("atest"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM information_schema.ins_tables WHERE db_name = 'atest' AND table_name = '" + table + "'")).
+			WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(0))
+	}
+
+	// Check, Current and the creation of schema_version
+	for i := 0; i < 3; i++ {
+		expectHasTable("schema_version")
+	}
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS `schema_version` (`ts` TIMESTAMP, `version` bigint, ")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, table := range []string{"test_cases", "test_suites", "history_test_results"} {
+		expectHasTable(table)
+		mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS `" + table + "` (`ts` TIMESTAMP, ")).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	expectVersion := func(version string) {
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `schema_version` (`ts`,`version`,`name`,`applied_at`) VALUES (") +
+			"'[^']+'," + version + ",").WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	expectVersion("1")
+	expectVersion("2")
+	// the create time column of the empty table is replaced
+	expectHistoryTime := func(count int) {
+		mock.ExpectQuery(regexp.QuoteMeta("DESCRIBE `history_test_results`")).
+			WillReturnRows(sqlmock.NewRows([]string{"field", "type", "length", "note"}).
+				AddRow("ts", "TIMESTAMP", 8, "").AddRow("id", "VARCHAR", 255, "").AddRow("create_time", "VARCHAR", 255, ""))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `history_test_results`")).
+			WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(count))
+	}
+	expectHistoryTime(0)
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `history_test_results` DROP COLUMN `create_time`")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta("ALTER TABLE `history_test_results` ADD COLUMN `create_time` TIMESTAMP")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	expectVersion("3")
+
+	applied, err := newSchemaMigrator(db, DialectorTDengine).Up(0, false)
+	assert.NoError(t, err)
+	assert.Len(t, applied, 3)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// TDengine cannot update the existing rows by key
+	expectHistoryTime(1)
+	assert.ErrorContains(t, convertHistoryTimeToUTC(db), "the create_time of 1 history records cannot be converted in tdengine")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestInterpolateTDengineParams(t *testing.T) {
+	created := time.Date(2025, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	name := "atest"
+	tests := []struct {
+		name   string
+		query  string
+		args   []interface{}
+		expect string
+		hasErr bool
+	}{{
+		name:   "no arguments",
+		query:  "SELECT '?' FROM t",
+		expect: "SELECT '?' FROM t",
+	}, {
+		name:   "literals",
+		query:  "INSERT INTO t VALUES (?, ?, ?, ?, ?, ?, ?)",
+		args:   []interface{}{created, `it's \\`, int32(-1), uint8(2), 1.5, true, nil},
+		expect: `INSERT INTO t VALUES ('2025-01-02T03:04:05.006Z', 'it\'s \\\\', -1, 2, 1.5, true, NULL)`,
+	}, {
+		name:   "placeholders in the quoted strings",
+		query:  "SELECT `a?` FROM t WHERE b = 'it\\'s?' AND c = ?",
+		args:   []interface{}{&name},
+		expect: "SELECT `a?` FROM t WHERE b = 'it\\'s?' AND c = 'atest'",
+	}, {
+		name:   "placeholders in the comments",
+		query:  "SELECT a -- why? \nFROM t /* a = ? */ WHERE a = ? AND b = ?",
+		args:   []interface{}{1, "b"},
+		expect: "SELECT a -- why? \nFROM t /* a = ? */ WHERE a = 1 AND b = 'b'",
+	}, {
+		name:   "valuer",
+		query:  "SELECT * FROM t WHERE a = ? AND b = ?",
+		args:   []interface{}{sql.NullString{String: "a", Valid: true}, sql.NullInt64{}},
+		expect: "SELECT * FROM t WHERE a = 'a' AND b = NULL",
+	}, {
+		name:   "not enough arguments",
+		query:  "SELECT * FROM t WHERE a = ? AND b = ?",
+		args:   []interface{}{1},
+		hasErr: true,
+	}, {
+		name:   "too many arguments",
+		query:  "SELECT * FROM t WHERE a = ?",
+		args:   []interface{}{1, 2},
+		hasErr: true,
+	}, {
+		name:   "unsupported type",
+		query:  "SELECT * FROM t WHERE a = ?",
+		args:   []interface{}{[]int{1}},
+		hasErr: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, err := interpolateTDengineParams(tt.query, tt.args)
+			if tt.hasErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.expect, query)
+			}
+		})
+	}
+}
+
+func TestTDengineLongText(t *testing.T) {
+	db, mock := newMockTDengineDB(t, sqlmock.QueryMatcherRegexp)
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT DATABASE()")).
+		WillReturnRows(sqlmock.NewRows([]string{"database()"}).AddRow("atest"))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT COUNT(*) FROM information_schema.ins_tables")).
+		WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(0))
+	mock.ExpectExec(regexp.QuoteMeta("CREATE TABLE IF NOT EXISTS `history_test_results` (`ts` TIMESTAMP, `id` VARCHAR(1024), ") +
+		".*" + regexp.QuoteMeta("`body` VARCHAR(8192), `header` VARCHAR(1024), ") +
+		".*" + regexp.QuoteMeta("`expect_body` VARCHAR(8192), `expect_schema` VARCHAR(8192), ") +
+		".*" + regexp.QuoteMeta("`output` VARCHAR(8192))")).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	assert.NoError(t, db.AutoMigrate(&HistoryTestResult{}))
+
+	// the payload larger than 1KB is stored and read as it is
+	body := strings.Repeat(`{"key": "it's a value"},`, 200)
+	literal, err := tdengineLiteral(body)
+	assert.NoError(t, err)
+	mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `history_test_results` (`ts`,`id`,") + ".*" +
+		regexp.QuoteMeta(","+literal+",")).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `history_test_results` WHERE id = 'large'")).
+		WillReturnRows(sqlmock.NewRows([]string{"ts", "id", "body"}).AddRow(time.Now(), "large", body))
+
+	assert.NoError(t, db.Create(&HistoryTestResult{ID: "large", Body: body}).Error)
+	var history HistoryTestResult
+	assert.NoError(t, db.Find(&history, "id = ?", "large").Error)
+	assert.Greater(t, len(history.Body), 4096)
+	assert.Equal(t, body, history.Body)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestNextTDengineTimestamp(t *testing.T) {
+	last := nextTDengineTimestamp()
+	for i := 0; i < 10; i++ {
+		next := nextTDengineTimestamp()
+		assert.True(t, next.After(last))
+		last = next
+	}
+}
+
+func TestTDengineMutations(t *testing.T) {
+	ts := time.Date(2025, 1, 2, 3, 4, 5, 6000000, time.UTC)
+	const tsLiteral = "'2025-01-02T03:04:05.006Z'"
+
+	t.Run("prune the history", func(t *testing.T) {
+		db, mock := newMockTDengineDB(t, sqlmock.QueryMatcherRegexp)
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT count(*) FROM `history_test_results`")).
+			WillReturnRows(sqlmock.NewRows([]string{"count(*)"}).AddRow(2))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT * FROM `history_test_results` ORDER BY create_time,`history_test_results`.`id` LIMIT 1")).
+			WillReturnRows(sqlmock.NewRows([]string{"ts", "id"}).AddRow(ts, "old"))
+		mock.ExpectQuery(regexp.QuoteMeta("SELECT `ts` FROM `history_test_results` WHERE id = 'old'")).
+			WillReturnRows(sqlmock.NewRows([]string{"ts"}).AddRow(ts))
+		mock.ExpectExec(regexp.QuoteMeta("DELETE FROM `history_test_results` WHERE `ts` = " + tsLiteral)).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta("INSERT INTO `history_test_results`")).WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, createHistory(db, &HistoryTestResult{ID: "new"}, 2))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete the history", func(t *testing.T) {
+		db, mock := newMockTDengineDB(t, sqlmock.QueryMatcherEqual)
+		mock.ExpectQuery("SELECT * FROM `history_test_results` WHERE id = 'id'").
+			WillReturnRows(sqlmock.NewRows([]string{"ts", "id"}).AddRow(ts, "id"))
+		mock.ExpectQuery("SELECT `ts` FROM `history_test_results` WHERE id = 'id'").
+			WillReturnRows(sqlmock.NewRows([]string{"ts"}).AddRow(ts))
+		mock.ExpectExec("DELETE FROM `history_test_results` WHERE `ts` = " + tsLiteral).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, deleteHistory(db, &HistoryTestResult{ID: "id"}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("update the test case", func(t *testing.T) {
+		db, mock := newMockTDengineDB(t, sqlmock.QueryMatcherEqual)
+		testcase := &TestCase{SuiteName: "suite", Name: "case", API: "/new"}
+		mock.ExpectQuery("SELECT * FROM `test_cases` WHERE suite_name = 'suite' AND name = 'case'").
+			WillReturnRows(sqlmock.NewRows([]string{"ts", "suite_name", "name", "api", "method"}).
+				AddRow(ts, "suite", "case", "/old", "GET"))
+		// the same timestamp overwrites the row
+		mock.ExpectExec("INSERT INTO `test_cases` (`ts`,`api`,`method`,`name`,`suite_name`) VALUES (" +
+			tsLiteral + ",'/new','GET','case','suite')").WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, updateTestCase(db, testcase, testcase))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("delete the test case", func(t *testing.T) {
+		db, mock := newMockTDengineDB(t, sqlmock.QueryMatcherEqual)
+		mock.ExpectQuery("SELECT `ts` FROM `test_cases` WHERE suite_name = 'suite' AND name = 'case'").
+			WillReturnRows(sqlmock.NewRows([]string{"ts"}).AddRow(ts).AddRow(ts.Add(time.Millisecond)))
+		mock.ExpectExec("DELETE FROM `test_cases` WHERE `ts` = " + tsLiteral).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec("DELETE FROM `test_cases` WHERE `ts` = '2025-01-02T03:04:05.007Z'").
+			WillReturnResult(sqlmock.NewResult(0, 1))
+
+		assert.NoError(t, deleteByTimestamp(testCaseIdentity(db, &TestCase{SuiteName: "suite", Name: "case"}), &TestCase{}))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,60 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const propTimezone = "timezone"
+
+// historyTimeLayout is the layout of the time part of a history record ID
+const historyTimeLayout = "2006-01-02T15:04:05.999999999"
+
+type timezoneContextKey struct{}
+
+// loadTimezone loads the location of the timezone property,
+// falls back to the default one, then the local time zone.
+func loadTimezone(properties map[string]string, defaultTimezone string) (loc *time.Location, err error) {
+	name := defaultTimezone
+	if v, ok := getProperty(properties, propTimezone); ok && v != "" {
+		name = v
+	}
+
+	if name == "" {
+		loc = time.Local
+	} else if loc, err = time.LoadLocation(name); err != nil {
+		err = fmt.Errorf("invalid %s %q: %v", propTimezone, name, err)
+	}
+	return
+}
+
+// withTimezone returns a context which carries the location for rendering time values
+func withTimezone(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, timezoneContextKey{}, loc)
+}
+
+// timezoneFromContext returns the location in the context, or the local time zone
+func timezoneFromContext(ctx context.Context) *time.Location {
+	if ctx != nil {
+		if loc, ok := ctx.Value(timezoneContextKey{}).(*time.Location); ok && loc != nil {
+			return loc
+		}
+	}
+	return time.Local
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/linuxsuren/api-testing/pkg/server"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestLoadTimezone(t *testing.T) {
+	loc, err := loadTimezone(nil, "")
+	assert.NoError(t, err)
+	assert.Equal(t, time.Local, loc)
+
+	loc, err = loadTimezone(nil, "UTC")
+	assert.NoError(t, err)
+	assert.Equal(t, "UTC", loc.String())
+
+	loc, err = loadTimezone(map[string]string{"timezone": "Asia/Tokyo"}, "UTC")
+	assert.NoError(t, err)
+	assert.Equal(t, "Asia/Tokyo", loc.String())
+
+	_, err = loadTimezone(map[string]string{"timezone": "Fake/Zone"}, "")
+	assert.Error(t, err)
+}
+
+func TestTimezoneContext(t *testing.T) {
+	assert.Equal(t, time.Local, timezoneFromContext(context.TODO()))
+	assert.Equal(t, time.UTC, timezoneFromContext(withTimezone(context.TODO(), time.UTC)))
+}
+
+func TestHistoryInTimezone(t *testing.T) {
+	tokyo, err := time.LoadLocation("Asia/Tokyo")
+	assert.NoError(t, err)
+
+	createTime := time.Date(2025, 1, 1, 20, 0, 0, 0, time.UTC)
+	result := ConvertToDBHistoryTestResult(&server.HistoryTestResult{
+		CreateTime: timestamppb.New(createTime),
+		Data: &server.HistoryTestCase{
+			SuiteName: "suite",
+			CaseName:  "case",
+		},
+	}, tokyo)
+	assert.Equal(t, "2025-01-02T05:00:00_suite_case", result.ID)
+	assert.Equal(t, "2025-1-2", result.HistorySuiteName)
+	assert.Equal(t, createTime, result.CreateTime)
+	assert.Equal(t, time.UTC, result.CreateTime.Location())
+}
+
+func TestQueryTimeInTimezone(t *testing.T) {
+	db, err := openMemoryDB()
+	assert.NoError(t, err)
+
+	type event struct {
+		Name      string
+		CreatedAt time.Time
+	}
+	assert.NoError(t, db.AutoMigrate(&event{}))
+	assert.NoError(t, db.Create(&event{
+		Name:      "test",
+		CreatedAt: time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC),
+	}).Error)
+
+	newYork, err := time.LoadLocation("America/New_York")
+	assert.NoError(t, err)
+
+	result, err := sqlQuery(withTimezone(context.TODO(), newYork), "SELECT created_at FROM events", db)
+	assert.NoError(t, err)
+	if assert.Len(t, result.Items, 1) {
+		assert.Equal(t, "2025-01-01 07:00:00 -0500 EST", result.Items[0].Data[0].Value)
+	}
+}
@@ -0,0 +1,230 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	mysqldriver "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/stdlib"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+const (
+	propSSLMode       = "sslMode"
+	propSSLRootCert   = "sslRootCert"
+	propSSLCert       = "sslCert"
+	propSSLKey        = "sslKey"
+	propSSLServerName = "sslServerName"
+)
+
+const (
+	SSLModeDisable    = "disable"
+	SSLModeRequire    = "require"
+	SSLModeVerifyCA   = "verify-ca"
+	SSLModeVerifyFull = "verify-full"
+)
+
+// tlsOptions represents the TLS settings of a store.
+// The certificates and key could be file paths or inline PEM.
+type tlsOptions struct {
+	Mode       string
+	RootCert   string
+	Cert       string
+	Key        string
+	ServerName string
+}
+
+func parseTLSOptions(properties map[string]string) (opts tlsOptions, err error) {
+	opts.Mode, _ = getProperty(properties, propSSLMode)
+	opts.RootCert, _ = getProperty(properties, propSSLRootCert)
+	opts.Cert, _ = getProperty(properties, propSSLCert)
+	opts.Key, _ = getProperty(properties, propSSLKey)
+	opts.ServerName, _ = getProperty(properties, propSSLServerName)
+
+	switch opts.Mode {
+	case "":
+		if opts.RootCert != "" || opts.Cert != "" {
+			opts.Mode = SSLModeVerifyFull
+		} else {
+			opts.Mode = SSLModeDisable
+		}
+	case SSLModeDisable, SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull:
+	default:
+		err = fmt.Errorf("invalid %s %q, should be one of %s/%s/%s/%s", propSSLMode, opts.Mode,
+			SSLModeDisable, SSLModeRequire, SSLModeVerifyCA, SSLModeVerifyFull)
+		return
+	}
+
+	if (opts.Cert == "") != (opts.Key == "") {
+		err = fmt.Errorf("%s and %s must be provided together", propSSLCert, propSSLKey)
+	}
+	return
+}
+
+func (o tlsOptions) enabled() bool {
+	return o.Mode != SSLModeDisable
+}
+
+// config builds the tls.Config, the host is used as the server name
+// when it was not specified.
+func (o tlsOptions) config(host string) (config *tls.Config, err error) {
+	if !o.enabled() {
+		return
+	}
+
+	config = &tls.Config{
+		ServerName: o.ServerName,
+	}
+	if config.ServerName == "" {
+		config.ServerName = host
+	}
+
+	if o.RootCert != "" {
+		var data []byte
+		if data, err = readPEM(o.RootCert); err != nil {
+			return
+		}
+		config.RootCAs = x509.NewCertPool()
+		if !config.RootCAs.AppendCertsFromPEM(data) {
+			err = errors.New("failed to parse the root certificate")
+			return
+		}
+	}
+
+	if o.Cert != "" {
+		var cert, key []byte
+		if cert, err = readPEM(o.Cert); err != nil {
+			return
+		}
+		if key, err = readPEM(o.Key); err != nil {
+			return
+		}
+
+		var pair tls.Certificate
+		if pair, err = tls.X509KeyPair(cert, key); err != nil {
+			return
+		}
+		config.Certificates = []tls.Certificate{pair}
+	}
+
+	switch o.Mode {
+	case SSLModeRequire:
+		config.InsecureSkipVerify = true
+	case SSLModeVerifyCA:
+		// verify the chain but not the host name, same as libpq
+		config.InsecureSkipVerify = true
+		config.VerifyPeerCertificate = verifyChain(config.RootCAs)
+	}
+	return
+}
+
+func verifyChain(roots *x509.CertPool) func([][]byte, [][]*x509.Certificate) error {
+	return func(rawCerts [][]byte, _ [][]*x509.Certificate) (err error) {
+		if len(rawCerts) == 0 {
+			return errors.New("no certificate from the server")
+		}
+
+		certs := make([]*x509.Certificate, len(rawCerts))
+		for i, raw := range rawCerts {
+			if certs[i], err = x509.ParseCertificate(raw); err != nil {
+				return
+			}
+		}
+
+		opts := x509.VerifyOptions{
+			Roots:         roots,
+			Intermediates: x509.NewCertPool(),
+		}
+		for _, cert := range certs[1:] {
+			opts.Intermediates.AddCert(cert)
+		}
+		_, err = certs[0].Verify(opts)
+		return
+	}
+}
+
+// registerMySQLTLS registers the TLS config to the MySQL driver,
+// returns the value of the tls parameter of DSN.
+func (o tlsOptions) registerMySQLTLS(host string) (name string, err error) {
+	var config *tls.Config
+	if config, err = o.config(host); err != nil || config == nil {
+		return
+	}
+
+	hash := sha256.Sum256([]byte(strings.Join([]string{o.Mode, o.RootCert, o.Cert, o.Key, config.ServerName}, "\x00")))
+	name = "atest-" + hex.EncodeToString(hash[:8])
+	err = mysqldriver.RegisterTLSConfig(name, config)
+	return
+}
+
+// newPostgresDialector creates the dialector with the TLS config
+// instead of the one derived from sslrootcert/sslcert/sslkey by pgx.
+func newPostgresDialector(dsn string, opts tlsOptions) (dialector gorm.Dialector, err error) {
+	if !opts.enabled() {
+		dialector = postgres.Open(dsn)
+		return
+	}
+
+	var config *pgx.ConnConfig
+	if config, err = pgx.ParseConfig(dsn); err != nil {
+		return
+	}
+	if config.TLSConfig, err = opts.config(config.Host); err != nil {
+		return
+	}
+	config.Fallbacks = nil
+
+	dialector = postgres.New(postgres.Config{
+		DSN:  dsn,
+		Conn: stdlib.OpenDB(*config),
+	})
+	return
+}
+
+// plaintextCredentialsWarning returns a warning if the password will be sent without TLS
+func plaintextCredentialsWarning(properties map[string]string, password string) (warning string) {
+	if password == "" {
+		return
+	}
+	if v, _ := getProperty(properties, propDSN); v != "" {
+		return
+	}
+
+	switch driver, _ := getProperty(properties, "driver"); driver {
+	case DialectorMySQL, "", DialectorGreptime, DialectorPostgres, DialectorClickHouse:
+		if opts, err := parseTLSOptions(properties); err == nil && !opts.enabled() {
+			warning = fmt.Sprintf("WARNING: the credentials are sent without TLS, set the property %s to enable it", propSSLMode)
+		}
+	}
+	return
+}
+
+func readPEM(value string) ([]byte, error) {
+	if strings.Contains(value, "-----BEGIN") {
+		return []byte(value), nil
+	}
+	return os.ReadFile(value)
+}
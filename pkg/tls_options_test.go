@@ -0,0 +1,221 @@
+/*
+Copyright 2025 API Testing Authors.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+	http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package pkg
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type testCert struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  string
+	kpem string
+}
+
+func newTestCert(t *testing.T, commonName string, parent *testCert) *testCert {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	signer, signerKey := template, key
+	if parent == nil {
+		template.IsCA = true
+		template.BasicConstraintsValid = true
+		template.KeyUsage = x509.KeyUsageCertSign
+	} else {
+		template.DNSNames = []string{commonName}
+		template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, signer, &key.PublicKey, signerKey)
+	assert.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	assert.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	assert.NoError(t, err)
+
+	return &testCert{
+		cert: cert,
+		key:  key,
+		pem:  string(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})),
+		kpem: string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})),
+	}
+}
+
+// startTLSServer starts a TLS server with a self-signed certificate
+func startTLSServer(t *testing.T, serverCert *testCert) string {
+	pair, err := tls.X509KeyPair([]byte(serverCert.pem), []byte(serverCert.kpem))
+	assert.NoError(t, err)
+
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{
+		Certificates: []tls.Certificate{pair},
+	})
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = listener.Close()
+	})
+
+	go func() {
+		for {
+			conn, acceptErr := listener.Accept()
+			if acceptErr != nil {
+				return
+			}
+			_ = conn.(*tls.Conn).Handshake()
+			_ = conn.Close()
+		}
+	}()
+	return listener.Addr().String()
+}
+
+func TestParseTLSOptions(t *testing.T) {
+	t.Run("default", func(t *testing.T) {
+		opts, err := parseTLSOptions(nil)
+		assert.NoError(t, err)
+		assert.False(t, opts.enabled())
+
+		var config *tls.Config
+		config, err = opts.config("localhost")
+		assert.NoError(t, err)
+		assert.Nil(t, config)
+	})
+
+	t.Run("verify-full by default when root cert provided", func(t *testing.T) {
+		opts, err := parseTLSOptions(map[string]string{"sslrootcert": "ca.pem"})
+		assert.NoError(t, err)
+		assert.Equal(t, SSLModeVerifyFull, opts.Mode)
+	})
+
+	t.Run("invalid mode", func(t *testing.T) {
+		_, err := parseTLSOptions(map[string]string{propSSLMode: "fake"})
+		assert.Error(t, err)
+	})
+
+	t.Run("cert without key", func(t *testing.T) {
+		_, err := parseTLSOptions(map[string]string{propSSLCert: "cert.pem"})
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid root cert", func(t *testing.T) {
+		_, err := tlsOptions{Mode: SSLModeVerifyFull, RootCert: "-----BEGIN fake"}.config("localhost")
+		assert.Error(t, err)
+
+		_, err = tlsOptions{Mode: SSLModeVerifyFull, RootCert: "not-exist.pem"}.config("localhost")
+		assert.Error(t, err)
+	})
+}
+
+func TestTLSOptionsConfig(t *testing.T) {
+	ca := newTestCert(t, "atest-ca", nil)
+	serverCert := newTestCert(t, "db.atest.local", ca)
+	clientCert := newTestCert(t, "client", ca)
+	address := startTLSServer(t, serverCert)
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	assert.NoError(t, os.WriteFile(caFile, []byte(ca.pem), 0600))
+
+	tests := []struct {
+		name    string
+		opts    tlsOptions
+		host    string
+		success bool
+	}{{
+		name:    "require without root cert",
+		opts:    tlsOptions{Mode: SSLModeRequire},
+		host:    "127.0.0.1",
+		success: true,
+	}, {
+		name:    "verify-ca ignores the host name",
+		opts:    tlsOptions{Mode: SSLModeVerifyCA, RootCert: ca.pem},
+		host:    "127.0.0.1",
+		success: true,
+	}, {
+		name: "verify-ca with an unknown root cert",
+		opts: tlsOptions{Mode: SSLModeVerifyCA, RootCert: newTestCert(t, "other", nil).pem},
+		host: "127.0.0.1",
+	}, {
+		name: "verify-full with a mismatched host name",
+		opts: tlsOptions{Mode: SSLModeVerifyFull, RootCert: caFile},
+		host: "127.0.0.1",
+	}, {
+		name:    "verify-full with the server name",
+		opts:    tlsOptions{Mode: SSLModeVerifyFull, RootCert: caFile, ServerName: "db.atest.local"},
+		host:    "127.0.0.1",
+		success: true,
+	}, {
+		name: "verify-full with client cert",
+		opts: tlsOptions{Mode: SSLModeVerifyFull, RootCert: ca.pem, Cert: clientCert.pem, Key: clientCert.kpem,
+			ServerName: "db.atest.local"},
+		host:    "127.0.0.1",
+		success: true,
+	}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := tt.opts.config(tt.host)
+			assert.NoError(t, err)
+
+			var conn *tls.Conn
+			conn, err = tls.DialWithDialer(&net.Dialer{Timeout: time.Second}, "tcp", address, config)
+			if tt.success {
+				assert.NoError(t, err)
+				_ = conn.Close()
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestRegisterMySQLTLS(t *testing.T) {
+	name, err := tlsOptions{Mode: SSLModeDisable}.registerMySQLTLS("localhost")
+	assert.NoError(t, err)
+	assert.Empty(t, name)
+
+	name, err = tlsOptions{Mode: SSLModeRequire}.registerMySQLTLS("localhost")
+	assert.NoError(t, err)
+	assert.NotEmpty(t, name)
+}
+
+func TestNewPostgresDialector(t *testing.T) {
+	const dsn = "host=localhost user=root password=root dbname=atest port=5432 sslmode=require"
+	dialector, err := newPostgresDialector(dsn, tlsOptions{Mode: SSLModeRequire})
+	assert.NoError(t, err)
+	assert.NotNil(t, dialector)
+
+	_, err = newPostgresDialector(dsn, tlsOptions{Mode: SSLModeRequire, RootCert: "not-exist.pem"})
+	assert.Error(t, err)
+}
@@ -15,6 +15,8 @@ limitations under the License.
 */
 package pkg
 
+import "time"
+
 type TestCase struct {
 	SuiteName string `json:"suiteName" gorm:"type:varchar(200);uniqueIndex:idx_name_and_suite_name"`
 	Name      string `gorm:"type:varchar(200);uniqueIndex:idx_name_and_suite_name"`
@@ -45,7 +47,8 @@ type TestSuite struct {
 type HistoryTestResult struct {
 	ID               string `gorm:"primaryKey"`
 	HistorySuiteName string
-	CreateTime       string
+	// CreateTime is stored in UTC
+	CreateTime time.Time `gorm:"index"`
 
 	//suite information
 	SuiteName string `json:"suiteName"`
@@ -80,6 +83,11 @@ type HistoryTestResult struct {
 }
 
 const (
-	DialectorPostgres = "postgres"
-	DialectorMySQL    = "mysql"
+	DialectorPostgres   = "postgres"
+	DialectorMySQL      = "mysql"
+	DialectorSQLServer  = "sqlserver"
+	DialectorClickHouse = "clickhouse"
+	DialectorDuckDB     = "duckdb"
+	DialectorTDengine   = "tdengine"
+	DialectorGreptime   = "greptime"
 )